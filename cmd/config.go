@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zliang90/kingRest/internal/app/conf"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+	"github.com/zliang90/kingRest/pkg/log"
+)
+
+// loadConfig loading the merged config of the env specified by `--env`
+func loadConfig(name string, args []string) (*conf.Config, error) {
+	return parseConfig(flag.NewFlagSet(name, flag.ExitOnError), args)
+}
+
+// parseConfig parses the args by fs with the `--env` flag, then loading
+// the merged config, the non-serve commands only print the warnings and
+// errors, and the gin engine is created in release mode
+func parseConfig(fs *flag.FlagSet, args []string) (*conf.Config, error) {
+	env := fs.String("env", "", "config env: dev/test/prod")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	log.SetLevel(log.WARNING)
+	gin.SetMode(gin.ReleaseMode)
+	return conf.Load(*cfgPath, *env)
+}
+
+func configValidate(args []string) error {
+	c, err := loadConfig("config validate", args)
+	if err != nil {
+		return err
+	}
+	m, err := errors.ParseMessages(c.ApiErrorFile)
+	if err != nil {
+		return fmt.Errorf("invalid api error file '%s', %v", c.ApiErrorFile, err)
+	}
+	if c.ApiErrorStrict {
+		if issues := m.Lint(c.ApiErrorLocale); len(issues) > 0 {
+			return fmt.Errorf("api error file '%s' has %d lint issues, see errors lint", c.ApiErrorFile, len(issues))
+		}
+	}
+	fmt.Printf("config of env '%s' is valid\n", c.Env)
+	return nil
+}
+
+// configPrint dump the merged config of the specified env
+func configPrint(args []string) error {
+	c, err := loadConfig("config print", args)
+	if err != nil {
+		return err
+	}
+	text, err := c.Dump()
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(text))
+	return nil
+}
+
+// errorsLint checks the api error file, fails if there are any issues
+func errorsLint(args []string) error {
+	c, err := loadConfig("errors lint", args)
+	if err != nil {
+		return err
+	}
+	m, err := errors.ParseMessages(c.ApiErrorFile)
+	if err != nil {
+		return fmt.Errorf("invalid api error file '%s', %v", c.ApiErrorFile, err)
+	}
+	issues := m.Lint(c.ApiErrorLocale)
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("%d issues found in '%s'", len(issues), c.ApiErrorFile)
+	}
+	fmt.Printf("%d api errors loaded from '%s'\n", len(m.Keys()), c.ApiErrorFile)
+	return nil
+}
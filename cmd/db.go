@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zliang90/kingRest/internal/app/db"
+	"github.com/zliang90/kingRest/pkg/util/table"
+)
+
+// openDB open the database connections of the config, without migration
+func openDB(name string, args []string) error {
+	c, err := loadConfig(name, args)
+	if err != nil {
+		return err
+	}
+	return db.Open(c)
+}
+
+func migrateUp(args []string) error {
+	if err := openDB("migrate up", args); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.MigrateUp(); err != nil {
+		return err
+	}
+	fmt.Println("migrate up done")
+	return nil
+}
+
+func migrateDown(args []string) error {
+	fs := flag.NewFlagSet("migrate down", flag.ExitOnError)
+	yes := fs.Bool("yes", false, "drop the tables without confirmation")
+	c, err := parseConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = db.Open(c); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if !*yes {
+		status, err := db.GetMigrationStatus()
+		if err != nil {
+			return err
+		}
+		tables := make([]string, 0, len(status))
+		for _, s := range status {
+			tables = append(tables, s.Table)
+		}
+		if !confirm(fmt.Sprintf("drop the tables %s of env '%s'?", strings.Join(tables, ", "), c.Env)) {
+			return fmt.Errorf("migrate down is canceled, run with --yes to skip the confirmation")
+		}
+	}
+
+	if err := db.MigrateDown(); err != nil {
+		return err
+	}
+	fmt.Println("migrate down done")
+	return nil
+}
+
+func migrateStatus(args []string) error {
+	if err := openDB("migrate status", args); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	status, err := db.GetMigrationStatus()
+	if err != nil {
+		return err
+	}
+	t := &table.Table{Header: []string{"TABLE", "STATUS"}}
+	for _, s := range status {
+		state := "pending"
+		if s.Exists {
+			state = "migrated"
+		}
+		t.Data = append(t.Data, []string{s.Table, state})
+	}
+	return t.PrintTable(true)
+}
+
+func seed(args []string) error {
+	if err := openDB("seed", args); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.Seed(); err != nil {
+		return err
+	}
+	fmt.Println("seed done")
+	return nil
+}
+
+// confirm asks the question on stdout, returns true if the answer is y or yes
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
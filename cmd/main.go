@@ -1,56 +1,98 @@
 package main
 
 import (
-	"context"
 	"flag"
-	"github.com/zliang90/kingRest/internal/app/conf"
-	"github.com/zliang90/kingRest/internal/app/db"
-	restApi "github.com/zliang90/kingRest/internal/restful"
-	"github.com/zliang90/kingRest/pkg/log"
+	"fmt"
 	"os"
-	"os/signal"
 	"runtime"
-	"time"
+	"strings"
+
+	"github.com/zliang90/kingRest/internal/app"
+	"github.com/zliang90/kingRest/pkg/log"
 )
 
+// command a cli command, the command with sub commands only dispatches
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+	subs  []*command
+}
+
+var cfgPath = flag.String("c", "config/config.yaml", "configuration file path")
+
+var commands = []*command{
+	{name: "serve", usage: "start the restful api server (default)", run: serve},
+	{name: "config", usage: "config file tools", subs: []*command{
+		{name: "validate", usage: "validate the config and api error files, [--env dev/test/prod]", run: configValidate},
+		{name: "print", usage: "print the merged config, [--env dev/test/prod]", run: configPrint},
+	}},
+	{name: "routes", usage: "print the route table", run: routes},
+	{name: "migrate", usage: "database migration", subs: []*command{
+		{name: "up", usage: "create or update the tables", run: migrateUp},
+		{name: "down", usage: "drop the tables, [--yes]", run: migrateDown},
+		{name: "status", usage: "print the migration status", run: migrateStatus},
+	}},
+	{name: "seed", usage: "initialize the default data", run: seed},
+	{name: "errors", usage: "api error file tools", subs: []*command{
+		{name: "lint", usage: "check the api error file", run: errorsLint},
+	}},
+	{name: "version", usage: "print the version", run: version},
+}
+
 func main() {
 	runtime.GOMAXPROCS(runtime.NumCPU() - 1)
 
-	cfgPath := flag.String("c", "config/config.yaml", "configuration file path")
+	flag.Usage = usage
 	flag.Parse()
 
-	var err error
-
-	// config
-	if err = conf.LoadConfig(*cfgPath); err != nil {
-		log.Fatal(err)
-		return
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"serve"}
 	}
-	log.Infof("load config: %s", conf.GetConfig().String())
-	// log level
-	log.SetLevel(conf.GetConfig().LogLevel)
-	// db
-	log.Info("init db connections")
-	if err := db.Init(conf.GetConfig()); err != nil {
+	if err := dispatch(commands, args, nil); err != nil {
 		log.Fatal(err)
-		return
 	}
+}
 
-	// root context
-	ctx, cancel := context.WithCancel(context.Background())
-	defer func() {
-		cancel()
-		time.Sleep(2 * time.Second)
-
-		log.Info("close db connections")
-		db.Close()
-	}()
-
-	// restful api
-	go restApi.New(conf.GetConfig()).Run(ctx)
+func dispatch(cmds []*command, args []string, parents []string) error {
+	for _, c := range cmds {
+		if len(args) == 0 || c.name != args[0] {
+			continue
+		}
+		if c.run != nil {
+			return c.run(args[1:])
+		}
+		return dispatch(c.subs, args[1:], append(parents, c.name))
+	}
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", strings.Join(append(parents, args[0]), " "))
+	}
+	usage()
+	os.Exit(2)
+	return nil
+}
 
-	quit := make(chan os.Signal)
-	signal.Notify(quit, os.Interrupt, os.Kill)
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] <command> [args]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		if c.run != nil {
+			fmt.Fprintf(os.Stderr, "  %-16s %s\n", c.name, c.usage)
+			continue
+		}
+		for _, sub := range c.subs {
+			fmt.Fprintf(os.Stderr, "  %-16s %s\n", c.name+" "+sub.name, sub.usage)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	flag.PrintDefaults()
+}
 
-	<-quit
+func version(_ []string) error {
+	v := app.Version
+	if v == "" {
+		v = "unknown"
+	}
+	fmt.Printf("kingrest %s %s %s/%s\n", v, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return nil
 }
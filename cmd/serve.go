@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/zliang90/kingRest/internal/app/conf"
+	"github.com/zliang90/kingRest/internal/app/db"
+	"github.com/zliang90/kingRest/internal/app/health"
+	"github.com/zliang90/kingRest/internal/app/lifecycle"
+	restApi "github.com/zliang90/kingRest/internal/restful"
+	"github.com/zliang90/kingRest/pkg/log"
+)
+
+// the new process should be ready before it on upgrade
+const upgradeTimeout = 60 * time.Second
+
+func serve(_ []string) error {
+	var err error
+
+	// config
+	if err = conf.LoadConfig(*cfgPath); err != nil {
+		return err
+	}
+	log.Infof("load config: %s", conf.GetConfig().String())
+	// log level
+	log.SetLevel(conf.GetConfig().LogLevel)
+	// health checks
+	initHealth(conf.GetConfig().Health)
+	// db
+	log.Info("init db connections")
+	if err = db.Init(conf.GetConfig()); err != nil {
+		return err
+	}
+	lifecycle.OnShutdown(lifecycle.PhaseStorage, "db connections", func(context.Context) error {
+		return db.Close()
+	})
+
+	// background workers
+	ctx, cancel := context.WithCancel(context.Background())
+	lifecycle.OnShutdown(lifecycle.PhaseWorkers, "background workers", func(context.Context) error {
+		cancel()
+		return nil
+	})
+
+	// reload config on SIGHUP or file changes
+	go conf.Watch(ctx, 5*time.Second)
+
+	// restful api
+	srv := restApi.New(conf.GetConfig())
+	if err = srv.Start(); err != nil {
+		lifecycle.Shutdown(context.Background())
+		return err
+	}
+	lifecycle.OnShutdown(lifecycle.PhaseHTTP, "restful api", srv.Shutdown)
+
+	// admin api
+	if admin := restApi.NewAdmin(conf.GetConfig(), srv); admin != nil {
+		if err = admin.Start(); err != nil {
+			lifecycle.Shutdown(context.Background())
+			return err
+		}
+		lifecycle.OnShutdown(lifecycle.PhaseHTTP, "admin api", admin.Shutdown)
+	}
+	lifecycle.SetReady(true)
+	// report ready to the parent process on upgrade
+	restApi.NotifyReady()
+
+	// SIGKILL can't be caught
+	quit := make(chan os.Signal, 2)
+	signal.Notify(quit, syscall.SIGTERM, os.Interrupt)
+
+	upgrade := make(chan os.Signal, 1)
+	if len(upgradeSignals) > 0 {
+		signal.Notify(upgrade, upgradeSignals...)
+	}
+
+	var sig os.Signal
+	for sig == nil {
+		select {
+		case sig = <-quit:
+		case s := <-upgrade:
+			log.Infof("received %v, upgrading", s)
+			if err := restApi.Upgrade(upgradeTimeout); err != nil {
+				log.Errorf("upgrade failed, keep serving: %v", err)
+				continue
+			}
+			sig = s
+		}
+	}
+	timeout := conf.GetConfig().WebServer.GetShutdownTimeout()
+	delay := conf.GetConfig().WebServer.GetReadinessDelay()
+	log.Infof("received %v, shutting down, readiness delay %v, drain timeout %v", sig, delay, timeout)
+	lifecycle.SetReadinessDelay(delay)
+
+	// force exit on the second signal
+	go func() {
+		sig := <-quit
+		log.Fatalf("received %v again, exit without draining", sig)
+	}()
+
+	// the drain timeout starts after the readiness delay
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), delay+timeout)
+	defer drainCancel()
+
+	return lifecycle.Shutdown(drainCtx)
+}
+
+func initHealth(c conf.Health) {
+	health.SetDefaults(
+		time.Duration(c.Timeout)*time.Second,
+		time.Duration(c.CacheTTL)*time.Second)
+
+	if c.DiskPath != "" {
+		health.Register("disk", health.DiskSpaceChecker(c.DiskPath, uint64(c.MinDiskFree)<<20),
+			health.Options{Liveness: true})
+	}
+}
+
+// routes print the route table without serving
+func routes(args []string) error {
+	c, err := loadConfig("routes", args)
+	if err != nil {
+		return err
+	}
+	restApi.New(c).PrintRouters()
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// upgradeSignals signals to upgrade the binary without downtime
+var upgradeSignals = []os.Signal{syscall.SIGUSR2}
@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package main
+
+import "os"
+
+// upgradeSignals upgrade is not supported on windows
+var upgradeSignals []os.Signal
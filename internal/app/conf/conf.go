@@ -4,14 +4,17 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"os"
 	"strings"
 	"sync"
 	"text/template"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	json "github.com/json-iterator/go"
 	"github.com/zliang90/kingRest/internal/restful/errors"
+	"github.com/zliang90/kingRest/internal/restful/pagination"
+	rules "github.com/zliang90/kingRest/internal/restful/validator"
 	"github.com/zliang90/kingRest/pkg/log"
 	"gopkg.in/yaml.v3"
 )
@@ -20,6 +23,9 @@ var (
 	config   *Config
 	validate *validator.Validate
 
+	// the config file path last loaded
+	configPath string
+
 	lock = new(sync.RWMutex)
 
 	funcs template.FuncMap
@@ -28,6 +34,8 @@ var (
 	rightDelim = "}}"
 )
 
+const defaultShutdownTimeout = 30 * time.Second
+
 type Config struct {
 	// App operation mode: dev/test/prod
 	Env string `validate:"oneof=dev test prod" yaml:"Env"`
@@ -35,27 +43,153 @@ type Config struct {
 	// restful api listen address, :8086
 	WebServer `validate:"required" yaml:"WebServer"`
 
+	// admin api listen address, serving pprof, health, metrics etc.
+	AdminServer *AdminServer `yaml:"AdminServer"`
+
 	// Log level: fatal, error, warning, info, debug
 	LogLevel string `validate:"oneof=debug info warning error fatal" yaml:"LogLevel"`
 
-	// Api error file
+	// Api error file, or a directory of per-locale files, eg: errors/en-US.yaml
 	ApiErrorFile string `validate:"required" yaml:"ApiErrorFile"`
 
+	// the locale of api error messages if none of Accept-Language matches, default zh-CN
+	ApiErrorLocale string `yaml:"ApiErrorLocale"`
+
+	// fail loading if the api error file has lint issues, otherwise they are logged
+	ApiErrorStrict bool `yaml:"ApiErrorStrict"`
+
+	// respond api errors with http status 200 for the legacy clients
+	ApiErrorLegacyStatus bool `yaml:"ApiErrorLegacyStatus"`
+
+	// default format of api errors: default/problem(RFC 7807),
+	// clients can also accept application/problem+json
+	ApiErrorFormat string `validate:"omitempty,oneof=default problem" yaml:"ApiErrorFormat"`
+
+	// base uri of the problem type, eg: https://example.com/errors
+	ApiProblemTypeBase string `yaml:"ApiProblemTypeBase"`
+
 	// database source
-	DataSources map[string]DataSource `validate:"required" yaml:"DataSources"`
+	DataSources map[string]DataSource `validate:"required,dive" yaml:"DataSources"`
+
+	// health checks
+	Health Health `yaml:"Health"`
+
+	// page size of the list apis
+	Pagination Pagination `yaml:"Pagination"`
+
+	// the effective source of each value, keyed by the yaml path
+	sources map[string]string
+
+	// the values read by the secret template functions
+	secrets []string
 }
 
 type WebServer struct {
+	// host:port or unix:///run/kingrest.sock, the listener inherited from
+	// systemd socket activation named "web" is preferred
 	Addr              string `yaml:"Addr" validate:"required"`
 	MaxHeaderBytes    int    `yaml:"MaxHeaderBytes" validate:"required"`
 	ReadTimeout       int    `yaml:"ReadTimeout" validate:"required"`
 	ReadHeaderTimeout int    `yaml:"ReadHeaderTimeout" validate:"required"`
 	WriteTimeout      int    `yaml:"WriteTimeout" validate:"required"`
 	IdleTimeout       int    `yaml:"IdleTimeout" validate:"required"`
+
+	// seconds to drain the in-flight requests on shutdown, default 30
+	ShutdownTimeout int `yaml:"ShutdownTimeout" validate:"gte=0"`
+
+	// seconds to keep serving after /readyz turns to 503 on shutdown, so
+	// that the load balancers stop routing to it before draining, default 0
+	ReadinessDelay int `yaml:"ReadinessDelay" validate:"gte=0"`
+
+	// file mode of the unix domain socket, eg: "0660"
+	SocketMode string `yaml:"SocketMode"`
+
+	// serving https if it's set
+	TLS *TLS `yaml:"TLS"`
+}
+
+type TLS struct {
+	// certificate and key files, reloaded without restart once modified
+	CertFile string `yaml:"CertFile" validate:"required"`
+	KeyFile  string `yaml:"KeyFile" validate:"required"`
+
+	// minimum tls version: 1.0/1.1/1.2/1.3, default 1.2
+	MinVersion string `yaml:"MinVersion" validate:"omitempty,oneof=1.0 1.1 1.2 1.3"`
+
+	// cipher suite names, eg: TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, default by golang
+	CipherSuites []string `yaml:"CipherSuites"`
+
+	// CA file to verify client certificates for mutual tls
+	ClientCAFile string `yaml:"ClientCAFile"`
+
+	// client certificate requirement: none/request/require/verify_if_given/require_and_verify
+	ClientAuth string `yaml:"ClientAuth" validate:"omitempty,oneof=none request require verify_if_given require_and_verify"`
+}
+
+// GetShutdownTimeout returns the drain deadline on shutdown
+func (w WebServer) GetShutdownTimeout() time.Duration {
+	if w.ShutdownTimeout == 0 {
+		return defaultShutdownTimeout
+	}
+	return time.Duration(w.ShutdownTimeout) * time.Second
+}
+
+// GetReadinessDelay returns the delay between the readiness turning
+// to not-ready and draining on shutdown
+func (w WebServer) GetReadinessDelay() time.Duration {
+	return time.Duration(w.ReadinessDelay) * time.Second
+}
+
+// Validate checks the client CA and the client auth of mutual tls, the
+// client certificates can't be verified without CA, and the CA is useless
+// without client auth
+func (t TLS) Validate() error {
+	switch t.ClientAuth {
+	case "verify_if_given", "require_and_verify":
+		if t.ClientCAFile == "" {
+			return fmt.Errorf("tls ClientCAFile is required by ClientAuth '%s'", t.ClientAuth)
+		}
+	case "":
+		if t.ClientCAFile != "" {
+			return fmt.Errorf("tls ClientCAFile '%s' is set without ClientAuth, eg: require_and_verify", t.ClientCAFile)
+		}
+	}
+	return nil
+}
+
+type AdminServer struct {
+	// listen address, eg: 127.0.0.1:8087 or unix:///run/kingrest-admin.sock,
+	// the listener inherited from systemd socket activation named "admin" is preferred
+	Addr string `yaml:"Addr" validate:"required"`
+
+	// file mode of the unix domain socket, eg: "0600"
+	SocketMode string `yaml:"SocketMode"`
+}
+
+type Health struct {
+	// seconds timeout of each check
+	Timeout int `yaml:"Timeout" validate:"gte=0"`
+
+	// seconds to cache the check results
+	CacheTTL int `yaml:"CacheTTL" validate:"gte=0"`
+
+	// the disk path to check free space, no check if empty
+	DiskPath string `yaml:"DiskPath"`
+
+	// minimum free space of the disk path in MB
+	MinDiskFree int `yaml:"MinDiskFree" validate:"gte=0"`
+}
+
+type Pagination struct {
+	// the page size if it's not specified, default 20
+	DefaultSize int `yaml:"DefaultSize" validate:"gte=0"`
+
+	// the larger page size is limited to it, default 100
+	MaxSize int `yaml:"MaxSize" validate:"gte=0"`
 }
 
 type DataSource struct {
-	Addr     string `yaml:"Addr"`
+	Addr     string `validate:"required" yaml:"Addr"`
 	IdleConn int    `yaml:"Idle"`
 	MaxConn  int    `yaml:"Max"`
 	Debug    bool   `yaml:"Debug"`
@@ -68,6 +202,9 @@ func init() {
 	if validate == nil {
 		validate = validator.New()
 		// validate.SetTagName("validate")
+
+		// share the custom validations with the api, see rules.RegisterValidation
+		rules.Attach(validate)
 	}
 
 	funcs = template.FuncMap{
@@ -93,6 +230,9 @@ func (c Config) Validate() (err error) {
 	}()
 
 	if err = validate.Struct(&c); err == nil {
+		if c.WebServer.TLS != nil {
+			return c.WebServer.TLS.Validate()
+		}
 		return nil
 	}
 
@@ -111,55 +251,148 @@ func (c Config) Validate() (err error) {
 	return err
 }
 
+// String returns the config in json format, the secrets are redacted
 func (c Config) String() string {
-	jsonBytes, err := json.Marshal(c)
+	jsonBytes, err := json.Marshal(struct {
+		Config
+		Sources map[string]string `json:"Sources,omitempty"`
+	}{redact(c), c.sources})
 	if err != nil {
 		log.Fatal(err)
 	}
 	return string(jsonBytes)
 }
 
-// LoadConfig loading config file to struct object
-func LoadConfig(cfgPath string) error {
-	text, err := ioutil.ReadFile(cfgPath)
+// Sources returns the effective source of each config value, keyed by the
+// yaml path, eg: "WebServer.Addr" => "env:KINGREST_WEBSERVER_ADDR"
+func (c Config) Sources() map[string]string {
+	return c.sources
+}
+
+// Load loading the layered config files to struct object without applying it,
+// the base file is merged with the env-specific overlay and the local override,
+// every field can be overridden by environment variable, see EnvPrefix.
+//
+// if `env` is empty, it's taken from KINGREST_ENV or the Env of the base file
+func Load(cfgPath, env string) (*Config, error) {
+	var (
+		c       Config
+		secrets []string
+	)
+
+	base, err := renderLayer(cfgPath, false, &secrets)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	c.sources = make(map[string]string)
+	markFileSources(base, nil, sourceFile+":"+cfgPath, c.sources)
 
-	var c Config
+	option := env
+	if env == "" {
+		env = os.Getenv(envName([]string{"Env"}))
+	}
+	if env == "" {
+		env, _ = base["Env"].(string)
+	}
 
-	// render config template
-	buf := new(bytes.Buffer)
-	t := template.Must(
-		template.New("config").
-			Delims(leftDelim, rightDelim).
-			Funcs(funcs).
-			Parse(string(text)))
-	if err = t.Execute(buf, nil); err != nil {
-		return err
+	// env-specific overlay and local override
+	for _, path := range layerPaths(cfgPath, env)[1:] {
+		tree, err := renderLayer(path, true, &secrets)
+		if err != nil {
+			return nil, err
+		}
+		if tree == nil {
+			continue
+		}
+		mergeTree(base, tree)
+		markFileSources(tree, nil, sourceFile+":"+path, c.sources)
 	}
-	if err = yaml.Unmarshal(buf.Bytes(), &c); err != nil {
-		return err
+	if env != "" {
+		base["Env"] = env
 	}
+
+	merged, err := yaml.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(merged, &c); err != nil {
+		return nil, err
+	}
+
+	// environment variables override
+	if err = applyEnv(&c, c.sources); err != nil {
+		return nil, err
+	}
+	// the env option overrides KINGREST_ENV, it's the env of the overlay merged
+	if option != "" {
+		c.Env = option
+		c.sources["Env"] = sourceOption
+	}
+	markSecrets(&c, secrets)
+
 	if err = c.Validate(); err != nil {
-		return err
+		return nil, err
 	}
 
 	// log level to uppercase
 	c.LogLevel = strings.ToUpper(c.LogLevel)
+	return &c, nil
+}
+
+// LoadConfig loading the layered config files and the api error file, and apply
+// them as the running config. everything is parsed and checked before applying,
+// the config, the api error templates and settings are kept if anything is invalid
+func LoadConfig(cfgPath string) error {
+	c, err := Load(cfgPath, "")
+	if err != nil {
+		return err
+	}
 
 	// error message
-	if err = errors.LoadMessages(c.ApiErrorFile); err != nil {
+	messages, err := errors.ParseMessages(c.ApiErrorFile)
+	if err != nil {
 		return err
 	}
+	issues := messages.Lint(c.ApiErrorLocale)
+	for _, issue := range issues {
+		log.Warningf("api error file '%s': %s", c.ApiErrorFile, issue)
+	}
+	if c.ApiErrorStrict && len(issues) > 0 {
+		return fmt.Errorf("api error file '%s' has %d lint issues", c.ApiErrorFile, len(issues))
+	}
+
 	lock.Lock()
 	defer lock.Unlock()
 
-	errors.SetEnv(c.Env)
-	config = &c
+	errors.Apply(errors.Settings{
+		Env:             c.Env,
+		DefaultLocale:   c.ApiErrorLocale,
+		LegacyStatus:    c.ApiErrorLegacyStatus,
+		Format:          c.ApiErrorFormat,
+		ProblemTypeBase: c.ApiProblemTypeBase,
+	}, messages)
+	pagination.SetSize(c.Pagination.DefaultSize, c.Pagination.MaxSize)
+	config = c
+	configPath = cfgPath
 	return nil
 }
 
+// Dump returns the merged config in yaml format, the secrets are redacted
+func (c Config) Dump() ([]byte, error) {
+	c = redact(c)
+
+	// keep the same format as the config file
+	c.LogLevel = strings.ToLower(c.LogLevel)
+
+	buf := new(bytes.Buffer)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), enc.Close()
+}
+
 func GetConfig() *Config {
 	lock.RLock()
 	defer lock.RUnlock()
@@ -0,0 +1,259 @@
+package conf
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix prefix of the environment variables which override config fields,
+// the variable name is the upper-case yaml path joined by '_', eg:
+//
+//	WebServer.Addr            => KINGREST_WEBSERVER_ADDR
+//	DataSources.default.Addr  => KINGREST_DATASOURCES_DEFAULT_ADDR
+const EnvPrefix = "KINGREST"
+
+// value sources reported by Config.String()
+const (
+	sourceDefault = "default"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+	sourceOption  = "option"
+
+	// appended to the source of the value rendered from secrets
+	sourceSecret = "secret"
+)
+
+// markFileSources records every leaf of the parsed yaml document as
+// coming from the config file
+func markFileSources(tree interface{}, path []string, source string, sources map[string]string) {
+	switch t := tree.(type) {
+	case map[string]interface{}:
+		for k, v := range t {
+			markFileSources(v, append(path, k), source, sources)
+		}
+	default:
+		if len(path) > 0 {
+			sources[strings.Join(path, ".")] = source
+		}
+	}
+}
+
+// applyEnv overrides config fields with environment variables,
+// fields without any source are reported as "default"
+func applyEnv(c *Config, sources map[string]string) error {
+	return walkEnv(reflect.ValueOf(c).Elem(), nil, sources)
+}
+
+func walkEnv(v reflect.Value, path []string, sources map[string]string) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name, inline := yamlName(f)
+			if name == "-" {
+				continue
+			}
+			fieldPath := path
+			if !inline {
+				fieldPath = append(append([]string{}, path...), name)
+			}
+			if err := walkEnv(v.Field(i), fieldPath, sources); err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			return setLeaf(v, path, sources)
+		}
+		if v.IsNil() {
+			// allocate optional blocks only when they are configured by env
+			if !hasEnvWithPrefix(envName(path) + "_") {
+				return nil
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return walkEnv(v.Elem(), path, sources)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return setLeaf(v, path, sources)
+		}
+		return walkEnvMap(v, path, sources)
+	default:
+		return setLeaf(v, path, sources)
+	}
+	return nil
+}
+
+// walkEnvMap walks the existing map entries, and adds the new entries
+// of the scalar maps which are only defined by env, the struct entries,
+// eg: datasources, must be declared in the config file, so that a typo
+// such as KINGREST_DATASOURCES_DEFUALT_ADDR is an error
+func walkEnvMap(v reflect.Value, path []string, sources map[string]string) error {
+	keys := make(map[string]string)
+	for _, k := range v.MapKeys() {
+		keys[envPart(k.String())] = k.String()
+	}
+
+	prefix := envName(path) + "_"
+	for _, kv := range os.Environ() {
+		name := strings.SplitN(kv, "=", 2)[0]
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(name, prefix)
+		if v.Type().Elem().Kind() == reflect.Struct {
+			// struct fields never contain '_', so the last part is the field name
+			i := strings.LastIndex(rest, "_")
+			if i <= 0 {
+				continue
+			}
+			if _, ok := keys[rest[:i]]; !ok {
+				return fmt.Errorf("invalid env %s, %s.%s is not in the config file",
+					name, strings.Join(path, "."), strings.ToLower(rest[:i]))
+			}
+			continue
+		}
+		if _, ok := keys[rest]; !ok {
+			keys[rest] = strings.ToLower(rest)
+		}
+	}
+
+	if len(keys) > 0 && v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	for _, k := range keys {
+		key := reflect.ValueOf(k)
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if old := v.MapIndex(key); old.IsValid() {
+			elem.Set(old)
+		}
+		if err := walkEnv(elem, append(append([]string{}, path...), k), sources); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+	}
+	return nil
+}
+
+func setLeaf(v reflect.Value, path []string, sources map[string]string) error {
+	key := strings.Join(path, ".")
+	name := envName(path)
+
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		if _, ok := sources[key]; !ok {
+			sources[key] = sourceDefault
+		}
+		return nil
+	}
+	if err := setValue(v, value); err != nil {
+		return fmt.Errorf("invalid value '%s' of %s, %v", value, name, err)
+	}
+	sources[key] = sourceEnv + ":" + name
+	return nil
+}
+
+func setValue(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(value, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		// comma separated list, eg: "a,b,c"
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		items := strings.Split(value, ",")
+		sl := reflect.MakeSlice(v.Type(), 0, len(items))
+		for _, item := range items {
+			if item = strings.TrimSpace(item); item != "" {
+				sl = reflect.Append(sl, reflect.ValueOf(item).Convert(v.Type().Elem()))
+			}
+		}
+		v.Set(sl)
+	case reflect.Ptr:
+		elem := reflect.New(v.Type().Elem())
+		if err := setValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(elem)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// yamlName returns the yaml key of the struct field
+func yamlName(f reflect.StructField) (name string, inline bool) {
+	tag := f.Tag.Get("yaml")
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "inline" {
+			return "", true
+		}
+	}
+	if parts[0] != "" {
+		return parts[0], false
+	}
+	return f.Name, false
+}
+
+// envName converts the yaml path to the environment variable name
+func envName(path []string) string {
+	name := EnvPrefix
+	for _, p := range path {
+		name += "_" + envPart(p)
+	}
+	return name
+}
+
+func envPart(p string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, p)
+}
+
+func hasEnvWithPrefix(prefix string) bool {
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package conf
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigWithEnv(t *testing.T) {
+	env := map[string]string{
+		"KINGREST_APIERRORFILE":              "../../../config/errors.yaml",
+		"KINGREST_WEBSERVER_ADDR":            "0.0.0.0:9000",
+		"KINGREST_DATASOURCES_DEFAULT_MAX":   "10",
+		"KINGREST_DATASOURCES_DEFAULT_DEBUG": "yes",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+	defer func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	}()
+
+	// invalid bool value
+	if err := LoadConfig("../../../config/config.yaml"); err == nil {
+		t.Fatal("expected error of invalid bool value")
+	}
+	os.Setenv("KINGREST_DATASOURCES_DEFAULT_DEBUG", "1")
+
+	// the datasource is not in the config file
+	os.Setenv("KINGREST_DATASOURCES_DEFUALT_ADDR", "root@tcp(127.0.0.1:3306)/typo")
+	err := LoadConfig("../../../config/config.yaml")
+	os.Unsetenv("KINGREST_DATASOURCES_DEFUALT_ADDR")
+	if err == nil || !strings.Contains(err.Error(), "KINGREST_DATASOURCES_DEFUALT_ADDR") {
+		t.Fatalf("expected error of unknown datasource, got %v", err)
+	}
+
+	// the address is required
+	os.Setenv("KINGREST_DATASOURCES_DEFAULT_ADDR", "")
+	err = LoadConfig("../../../config/config.yaml")
+	os.Unsetenv("KINGREST_DATASOURCES_DEFAULT_ADDR")
+	if err == nil {
+		t.Fatal("expected error of empty datasource addr")
+	}
+
+	if err := LoadConfig("../../../config/config.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	c := GetConfig()
+	if c.WebServer.Addr != "0.0.0.0:9000" {
+		t.Errorf("unexpected addr: %s", c.WebServer.Addr)
+	}
+	if ds := c.DataSources["default"]; ds.MaxConn != 10 || !ds.Debug || ds.IdleConn != 2 {
+		t.Errorf("unexpected default datasource: %+v", ds)
+	}
+	if _, ok := c.DataSources["defualt"]; ok || len(c.DataSources) != 1 {
+		t.Errorf("unexpected datasources: %+v", c.DataSources)
+	}
+	if s := c.Sources()["WebServer.Addr"]; s != "env:KINGREST_WEBSERVER_ADDR" {
+		t.Errorf("unexpected source of WebServer.Addr: %s", s)
+	}
+	if s := c.Sources()["WebServer.IdleTimeout"]; s != "file:../../../config/config.yaml" {
+		t.Errorf("unexpected source of WebServer.IdleTimeout: %s", s)
+	}
+	if s := c.Sources()["DataSources.default.Max"]; s != "env:KINGREST_DATASOURCES_DEFAULT_MAX" {
+		t.Errorf("unexpected source of DataSources.default.Max: %s", s)
+	}
+	t.Log(c.String())
+}
@@ -0,0 +1,75 @@
+package conf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// layerPaths returns the config files merged in order:
+// the base file, the env-specific overlay and the local override, eg:
+//
+//	config/config.yaml => config/config.prod.yaml => config/config.local.yaml
+func layerPaths(cfgPath, env string) []string {
+	ext := filepath.Ext(cfgPath)
+	name := strings.TrimSuffix(cfgPath, ext)
+
+	paths := []string{cfgPath}
+	if env != "" {
+		paths = append(paths, name+"."+env+ext)
+	}
+	return append(paths, name+".local"+ext)
+}
+
+// renderLayer render the config template and parse it to yaml tree,
+// the overlay files are optional, a missing one returns nil tree
+func renderLayer(path string, optional bool, secrets *[]string) (map[string]interface{}, error) {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		if optional && os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	t, err := template.New(filepath.Base(path)).
+		Delims(leftDelim, rightDelim).
+		Funcs(secretFuncs(secrets)).
+		Parse(string(text))
+	if err != nil {
+		return nil, err
+	}
+	if err = t.Execute(buf, nil); err != nil {
+		return nil, err
+	}
+
+	tree := make(map[string]interface{})
+	if err = yaml.Unmarshal(buf.Bytes(), &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// mergeTree deep merge src into dst, the maps are merged recursively,
+// the scalars and lists of src replace the values of dst
+func mergeTree(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, ok := v.(map[string]interface{})
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		dstMap, ok := dst[k].(map[string]interface{})
+		if !ok {
+			dstMap = make(map[string]interface{})
+			dst[k] = dstMap
+		}
+		mergeTree(dstMap, srcMap)
+	}
+}
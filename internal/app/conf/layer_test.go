@@ -0,0 +1,97 @@
+package conf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMergeTree(t *testing.T) {
+	dst := map[string]interface{}{
+		"Env": "dev",
+		"WebServer": map[string]interface{}{
+			"Addr":        ":8086",
+			"ReadTimeout": 300,
+			"TLS":         map[string]interface{}{"CertFile": "a.crt", "KeyFile": "a.key"},
+		},
+		"CipherSuites": []interface{}{"A", "B"},
+		"LogLevel":     "debug",
+	}
+	src := map[string]interface{}{
+		"Env": "prod",
+		"WebServer": map[string]interface{}{
+			"Addr": ":80",
+			"TLS":  map[string]interface{}{"CertFile": "b.crt"},
+		},
+		"CipherSuites": []interface{}{"C"},
+		"AdminServer":  map[string]interface{}{"Addr": ":8087"},
+	}
+	mergeTree(dst, src)
+
+	want := map[string]interface{}{
+		"Env": "prod",
+		"WebServer": map[string]interface{}{
+			"Addr":        ":80",
+			"ReadTimeout": 300,
+			"TLS":         map[string]interface{}{"CertFile": "b.crt", "KeyFile": "a.key"},
+		},
+		"CipherSuites": []interface{}{"C"},
+		"LogLevel":     "debug",
+		"AdminServer":  map[string]interface{}{"Addr": ":8087"},
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("mergeTree =\n%v\nwant\n%v", dst, want)
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kingrest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfgPath := writeReloadConfig(t, dir, "zh-CN", false, false, 10)
+	layers := map[string]string{
+		"config.prod.yaml":  "Env: prod\nWebServer:\n  Addr: 0.0.0.0:80\n  ReadTimeout: 60\nLogLevel: error\n",
+		"config.dev.yaml":   "WebServer:\n  Addr: 127.0.0.1:9999\n",
+		"config.local.yaml": "WebServer:\n  Addr: 0.0.0.0:8080\n",
+	}
+	for name, content := range layers {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// KINGREST_ENV is overridden by the env option
+	os.Setenv("KINGREST_ENV", "dev")
+	defer os.Unsetenv("KINGREST_ENV")
+
+	c, err := Load(cfgPath, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Env != "prod" || c.Sources()["Env"] != sourceOption {
+		t.Errorf("unexpected env %s from %s", c.Env, c.Sources()["Env"])
+	}
+	// base => prod => local
+	if c.WebServer.Addr != "0.0.0.0:8080" || c.WebServer.ReadTimeout != 60 || c.WebServer.IdleTimeout != 600 {
+		t.Errorf("unexpected web server %+v", c.WebServer)
+	}
+	if c.LogLevel != "ERROR" {
+		t.Errorf("unexpected log level %s", c.LogLevel)
+	}
+	if s := c.Sources()["WebServer.ReadTimeout"]; s != sourceFile+":"+filepath.Join(dir, "config.prod.yaml") {
+		t.Errorf("unexpected source of ReadTimeout: %s", s)
+	}
+
+	// KINGREST_ENV without the option
+	if c, err = Load(cfgPath, ""); err != nil {
+		t.Fatal(err)
+	}
+	if c.Env != "dev" || c.WebServer.ReadTimeout != 300 || c.WebServer.Addr != "0.0.0.0:8080" {
+		t.Errorf("unexpected config of dev: %s %+v", c.Env, c.WebServer)
+	}
+}
@@ -0,0 +1,154 @@
+package conf
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/zliang90/kingRest/pkg/log"
+)
+
+// Subscriber is notified after the config is reloaded
+type Subscriber func(old, new *Config)
+
+var subscribers []Subscriber
+
+// the log level set at runtime, eg: by the admin api,
+// it's kept on reloading until it's cleared
+var logLevelOverride string
+
+// Subscribe register a subscriber of config reloading, eg: resize db pools
+func Subscribe(fn Subscriber) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	subscribers = append(subscribers, fn)
+}
+
+// Reload reloading the config file last loaded and the api error file,
+// the running config is kept if the new version is invalid
+func Reload() error {
+	lock.RLock()
+	old, path := config, configPath
+	subs := make([]Subscriber, len(subscribers))
+	copy(subs, subscribers)
+	lock.RUnlock()
+
+	if err := LoadConfig(path); err != nil {
+		return err
+	}
+	c := GetConfig()
+
+	// log level, unless it's overridden at runtime
+	log.SetLevel(GetLogLevel())
+
+	for _, fn := range subs {
+		fn(old, c)
+	}
+	return nil
+}
+
+// OverrideLogLevel sets the log level at runtime, it's kept on reloading until
+// it's cleared by the empty level, then the configured level is restored
+func OverrideLogLevel(level string) {
+	lock.Lock()
+	logLevelOverride = level
+	lock.Unlock()
+
+	log.SetLevel(GetLogLevel())
+}
+
+// GetLogLevel returns the log level overridden at runtime, or the configured one
+func GetLogLevel() string {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	if logLevelOverride != "" {
+		return logLevelOverride
+	}
+	if config == nil {
+		return ""
+	}
+	return config.LogLevel
+}
+
+// LogLevelOverridden whether the log level is overridden at runtime
+func LogLevelOverridden() bool {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return logLevelOverride != ""
+}
+
+// Watch reloading config on SIGHUP or modification of the config files,
+// it blocks until the context is done
+func Watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	modTimes := watchedModTimes()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Info("received SIGHUP, reload config")
+		case <-ticker.C:
+			if !modified(modTimes) {
+				continue
+			}
+			log.Info("config files changed, reload config")
+		}
+
+		if err := Reload(); err != nil {
+			log.Errorf("reload config failed, keep the running config: %v", err)
+		} else {
+			log.Infof("reload config: %s", GetConfig().String())
+		}
+		modTimes = watchedModTimes()
+	}
+}
+
+// watchedFiles config files of every layer and api error file
+func watchedFiles() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	if config == nil {
+		return nil
+	}
+	files := append(layerPaths(configPath, config.Env), config.ApiErrorFile)
+
+	// per-locale api error files
+	if fi, err := os.Stat(config.ApiErrorFile); err == nil && fi.IsDir() {
+		paths, _ := filepath.Glob(filepath.Join(config.ApiErrorFile, "*.yaml"))
+		files = append(files, paths...)
+	}
+	return files
+}
+
+func watchedModTimes() map[string]time.Time {
+	modTimes := make(map[string]time.Time)
+	for _, f := range watchedFiles() {
+		if fi, err := os.Stat(f); err == nil {
+			modTimes[f] = fi.ModTime()
+		}
+	}
+	return modTimes
+}
+
+func modified(modTimes map[string]time.Time) bool {
+	for f, t := range watchedModTimes() {
+		if !t.Equal(modTimes[f]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package conf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/zliang90/kingRest/internal/restful/errors"
+	"github.com/zliang90/kingRest/pkg/log"
+)
+
+const reloadConfig = `Env: test
+WebServer:
+  Addr: 127.0.0.1:8086
+  MaxHeaderBytes: 1048576
+  ReadTimeout: 300
+  ReadHeaderTimeout: 300
+  WriteTimeout: 300
+  IdleTimeout: 600
+LogLevel: info
+ApiErrorFile: %s
+ApiErrorLocale: %s
+ApiErrorStrict: %v
+ApiErrorLegacyStatus: %v
+Pagination:
+  DefaultSize: %d
+DataSources:
+  default:
+    Addr: root@tcp(127.0.0.1:3306)/test
+`
+
+// writeReloadConfig writes the config and the api error file to dir,
+// the api error file has a duplicated code if `broken`
+func writeReloadConfig(t *testing.T, dir, locale string, strict, broken bool, size int) string {
+	data, err := ioutil.ReadFile("../../../config/errors.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if broken {
+		data = append(data, "\nDUPLICATED:\n  code: 1000404\n  message: \"duplicated\"\n"...)
+	}
+	errorFile := filepath.Join(dir, "errors.yaml")
+	if err = ioutil.WriteFile(errorFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfgPath := filepath.Join(dir, "config.yaml")
+	content := fmt.Sprintf(reloadConfig, errorFile, locale, strict, size%2 == 0, size)
+	if err = ioutil.WriteFile(cfgPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return cfgPath
+}
+
+func TestReloadConcurrently(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kingrest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err = LoadConfig(writeReloadConfig(t, dir, "zh-CN", false, false, 10)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the requests read the settings and the templates while reloading
+	done := make(chan struct{})
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				e := errors.NewLocalizedAPIError("en-US", errors.KeyNotFound, errors.Params{"resource": "user"})
+				_ = e.HTTPStatus()
+				_ = e.Problem("/users")
+				_ = errors.Catalog()
+				_ = errors.Locale("en")
+				_ = GetConfig().Pagination.DefaultSize
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		locale := []string{"zh-CN", "en-US"}[i%2]
+		writeReloadConfig(t, dir, locale, false, false, 10+i)
+		if err = Reload(); err != nil {
+			t.Error(err)
+		}
+		if s := errors.GetSettings(); s.DefaultLocale != locale || s.LegacyStatus != (i%2 == 0) {
+			t.Errorf("reload %d: unexpected settings %+v", i, s)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestReloadRejectedByLint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kingrest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err = LoadConfig(writeReloadConfig(t, dir, "zh-CN", false, false, 10)); err != nil {
+		t.Fatal(err)
+	}
+	keys := errors.Keys()
+
+	// the broken api error file is rejected in strict mode
+	writeReloadConfig(t, dir, "en-US", true, true, 30)
+	if err = Reload(); err == nil {
+		t.Fatal("expected lint error")
+	}
+	if s := errors.GetSettings(); s.DefaultLocale != "zh-CN" {
+		t.Errorf("the locale is applied by the rejected config: %+v", s)
+	}
+	if got := errors.Keys(); len(got) != len(keys) {
+		t.Errorf("the templates are applied by the rejected config: %v", got)
+	}
+	if c := GetConfig(); c.ApiErrorLocale != "zh-CN" || c.Pagination.DefaultSize != 10 {
+		t.Errorf("the rejected config is applied: %s", c)
+	}
+
+	// the issues are warnings only without strict mode
+	writeReloadConfig(t, dir, "en-US", false, true, 30)
+	if err = Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if s := errors.GetSettings(); s.DefaultLocale != "en-US" {
+		t.Errorf("unexpected settings %+v", s)
+	}
+}
+
+func TestReloadKeepsLogLevelOverride(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kingrest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err = LoadConfig(writeReloadConfig(t, dir, "zh-CN", false, false, 10)); err != nil {
+		t.Fatal(err)
+	}
+	OverrideLogLevel("DEBUG")
+	defer OverrideLogLevel("")
+
+	if err = Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if level := log.GetLevel().String(); level != "DEBUG" || !LogLevelOverridden() {
+		t.Errorf("the override is reverted by reloading: %s", level)
+	}
+
+	OverrideLogLevel("")
+	if level := log.GetLevel().String(); level != "INFO" || LogLevelOverridden() {
+		t.Errorf("the configured level is not restored: %s", level)
+	}
+}
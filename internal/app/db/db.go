@@ -7,6 +7,7 @@ import (
 	_ "github.com/jinzhu/gorm/dialects/mysql"
 	"github.com/zliang90/kingRest/internal/app/conf"
 	"github.com/zliang90/kingRest/internal/app/db/callbacks"
+	"github.com/zliang90/kingRest/internal/app/health"
 	"github.com/zliang90/kingRest/pkg/log"
 )
 
@@ -18,11 +19,40 @@ var (
 	dbs = make(map[string]*gorm.DB)
 )
 
-// Init init default db
+// Init init default db, migrate the models and initialize data
 func Init(c *conf.Config) error {
 	if _db != nil {
 		return nil
 	}
+	if err := Open(c); err != nil {
+		return err
+	}
+	// Auto migrate
+	log.Info("auto db migration")
+	if err := MigrateUp(); err != nil {
+		return err
+	}
+
+	// data initialize
+	if c.Env != "prod" {
+		if err := Seed(); err != nil {
+			log.Error(err)
+		}
+	}
+
+	// apply pool settings on config reloading
+	conf.Subscribe(reloadDataSources)
+
+	// connection checks
+	for k, db := range dbs {
+		health.Register("db:"+k, db.DB().PingContext, health.Options{Critical: true})
+	}
+
+	return nil
+}
+
+// Open open the database connections without migration
+func Open(c *conf.Config) error {
 	if c == nil {
 		return fmt.Errorf("config is nil")
 	}
@@ -76,23 +106,30 @@ func initDataSources(c *conf.Config) error {
 	if _db, ok = dbs["default"]; !ok {
 		return fmt.Errorf("the 'default' datasource is not defined")
 	}
-	// Auto migrate
-	log.Info("auto db migration")
 	// defaultDB.Set("gorm:table_options", "ENGINE=InnoDB DEFAULT CHARSET=utf8")
-	_db.AutoMigrate(
-		&User{},
-	)
+
 	// Replace delete callback
 	_db.Callback().Delete().Replace("gorm:delete", callbacks.DeleteCallback)
 
-	// data initialize
-	if c.Env != "prod" {
-		dataInitialize()
-	}
-
 	return nil
 }
 
+func reloadDataSources(_, c *conf.Config) {
+	for k, ds := range c.DataSources {
+		db, ok := dbs[k]
+		if !ok {
+			log.Warningf("the '%s' datasource is added, restart to take effect", k)
+			continue
+		}
+		db.DB().SetMaxIdleConns(ds.IdleConn)
+		db.DB().SetMaxOpenConns(ds.MaxConn)
+		db.LogMode(ds.Debug)
+		if ds.Debug {
+			db.SetLogger(log.GetLogger())
+		}
+	}
+}
+
 // GetDefaultDB get the default db object
 func GetDefaultDB() *gorm.DB {
 	return _db
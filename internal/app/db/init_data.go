@@ -1,17 +1,12 @@
 package db
 
-import (
-	"github.com/zliang90/kingRest/pkg/log"
-)
-
-func dataInitialize() {
+// Seed initialize the default data
+func Seed() error {
 	// user
 	u1 := User{
 		Name:     "admin",
 		Password: "admin123",
 	}
 
-	if err := _db.FirstOrCreate(&u1).Error; err != nil {
-		log.Error(err)
-	}
+	return _db.FirstOrCreate(&u1).Error
 }
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"fmt"
+)
+
+// models migrated in order
+var models = []interface{}{
+	&User{},
+}
+
+// MigrationStatus the migration status of a model table
+type MigrationStatus struct {
+	Table  string
+	Exists bool
+}
+
+// MigrateUp create or update the tables of models
+func MigrateUp() error {
+	if _db == nil {
+		return fmt.Errorf("the default db is not opened")
+	}
+	return _db.AutoMigrate(models...).Error
+}
+
+// MigrateDown drop the tables of models in reverse order
+func MigrateDown() error {
+	if _db == nil {
+		return fmt.Errorf("the default db is not opened")
+	}
+	for i := len(models) - 1; i >= 0; i-- {
+		if err := _db.DropTableIfExists(models[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetMigrationStatus returns whether the table of each model exists
+func GetMigrationStatus() ([]MigrationStatus, error) {
+	if _db == nil {
+		return nil, fmt.Errorf("the default db is not opened")
+	}
+	status := make([]MigrationStatus, 0, len(models))
+	for _, m := range models {
+		status = append(status, MigrationStatus{
+			Table:  _db.NewScope(m).TableName(),
+			Exists: _db.HasTable(m),
+		})
+	}
+	return status, nil
+}
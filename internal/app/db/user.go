@@ -4,6 +4,6 @@ package db
 type User struct {
 	BaseModel
 
-	Name     string `gorm:"type:varchar(128);"`
-	Password string `gorm:"type:varchar(128);"`
+	Name     string `gorm:"type:varchar(128);" json:"name"`
+	Password string `gorm:"type:varchar(128);" json:"-"`
 }
@@ -0,0 +1,21 @@
+package health
+
+import (
+	"context"
+	"fmt"
+)
+
+// DiskSpaceChecker checks the free space of the path is more than minFree bytes
+func DiskSpaceChecker(path string, minFree uint64) CheckerFunc {
+	return func(context.Context) error {
+		free, err := diskFree(path)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("free space of '%s' is %d MB, less than %d MB",
+				path, free>>20, minFree>>20)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package health
+
+import "fmt"
+
+func diskFree(path string) (uint64, error) {
+	return 0, fmt.Errorf("disk space check is not supported on windows")
+}
@@ -0,0 +1,214 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zliang90/kingRest/internal/app/lifecycle"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+var (
+	defaultTimeout  = 2 * time.Second
+	defaultCacheTTL = 5 * time.Second
+)
+
+// CheckerFunc checks a dependency, returns nil if it's healthy
+type CheckerFunc func(ctx context.Context) error
+
+// Options options of a checker
+type Options struct {
+	// the failure makes the report down
+	Critical bool
+
+	// also checked by liveness, only for the checks of the process itself
+	Liveness bool
+
+	// check timeout, default 2s, see SetDefaults
+	Timeout time.Duration
+
+	// the result is cached for ttl, default 5s, see SetDefaults
+	CacheTTL time.Duration
+}
+
+// Result check result
+type Result struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Critical  bool      `json:"critical"`
+	Latency   string    `json:"latency"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report check results of all checkers
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+type checker struct {
+	name string
+	fn   CheckerFunc
+	opts Options
+
+	mu     sync.Mutex
+	result *Result
+}
+
+var (
+	checkers = make(map[string]*checker)
+	lock     = new(sync.RWMutex)
+)
+
+// SetDefaults set the default timeout and cache ttl of the checkers registered later
+func SetDefaults(timeout, cacheTTL time.Duration) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if timeout > 0 {
+		defaultTimeout = timeout
+	}
+	if cacheTTL > 0 {
+		defaultCacheTTL = cacheTTL
+	}
+}
+
+// Register register a named checker, the checker with the same name is replaced
+func Register(name string, fn CheckerFunc, opts Options) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = defaultCacheTTL
+	}
+
+	checkers[name] = &checker{name: name, fn: fn, opts: opts}
+}
+
+// Unregister remove the named checker
+func Unregister(name string) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	delete(checkers, name)
+}
+
+// Liveness runs the liveness checkers
+func Liveness(ctx context.Context) Report {
+	return run(ctx, true)
+}
+
+// Readiness runs all checkers, the report is down if the app is not ready,
+// eg: it's shutting down
+func Readiness(ctx context.Context) Report {
+	r := run(ctx, false)
+	if !lifecycle.Ready() {
+		r.Status = StatusDown
+		r.Checks = append([]Result{{
+			Name:      "lifecycle",
+			Status:    StatusDown,
+			Critical:  true,
+			Latency:   "0s",
+			Error:     "not ready",
+			CheckedAt: time.Now(),
+		}}, r.Checks...)
+	}
+	return r
+}
+
+func run(ctx context.Context, liveness bool) Report {
+	lock.RLock()
+	cs := make([]*checker, 0, len(checkers))
+	for _, c := range checkers {
+		if !liveness || c.opts.Liveness {
+			cs = append(cs, c)
+		}
+	}
+	lock.RUnlock()
+
+	sort.Slice(cs, func(i, j int) bool {
+		return cs[i].name < cs[j].name
+	})
+
+	r := Report{Status: StatusUp, Checks: make([]Result, len(cs))}
+
+	var wg sync.WaitGroup
+	for i, c := range cs {
+		wg.Add(1)
+		go func(i int, c *checker) {
+			defer wg.Done()
+			r.Checks[i] = c.check(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, res := range r.Checks {
+		if res.Critical && res.Status != StatusUp {
+			r.Status = StatusDown
+		}
+	}
+	return r
+}
+
+// check runs the checker with timeout, or returns the cached result. the
+// checker doesn't run on the context of the caller, so that the result of a
+// cancelled request isn't cached as the status of the dependency
+func (c *checker) check(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.result != nil && time.Since(c.result.CheckedAt) < c.opts.CacheTTL {
+		return *c.result
+	}
+
+	checkCtx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if e := recover(); e != nil {
+				errCh <- fmt.Errorf("%v", e)
+			}
+		}()
+		errCh <- c.fn(checkCtx)
+	}()
+
+	var err error
+	cancelled := false
+	select {
+	case err = <-errCh:
+	case <-checkCtx.Done():
+		err = fmt.Errorf("check timeout after %v", c.opts.Timeout)
+	case <-ctx.Done():
+		err, cancelled = fmt.Errorf("check cancelled: %v", ctx.Err()), true
+	}
+
+	res := Result{
+		Name:      c.name,
+		Status:    StatusUp,
+		Critical:  c.opts.Critical,
+		Latency:   time.Since(start).String(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+	if !cancelled {
+		c.result = &res
+	}
+	return res
+}
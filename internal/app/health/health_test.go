@@ -0,0 +1,196 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zliang90/kingRest/internal/app/lifecycle"
+)
+
+// reset removes the checkers of the previous test
+func reset() {
+	lock.Lock()
+	checkers = make(map[string]*checker)
+	lock.Unlock()
+}
+
+func TestReport(t *testing.T) {
+	defer reset()
+	lifecycle.SetReady(true)
+
+	healthy := func(context.Context) error { return nil }
+	failed := func(context.Context) error { return errors.New("connection refused") }
+	panicked := func(context.Context) error { panic("nil pointer") }
+
+	cases := []struct {
+		name     string
+		checkers map[string]CheckerFunc
+		critical map[string]bool
+		status   string
+		errors   map[string]string
+	}{
+		{"no checks", nil, nil, StatusUp, nil},
+		{"healthy", map[string]CheckerFunc{"db": healthy, "cache": healthy},
+			map[string]bool{"db": true}, StatusUp, nil},
+		{"critical failed", map[string]CheckerFunc{"db": failed, "cache": healthy},
+			map[string]bool{"db": true}, StatusDown, map[string]string{"db": "connection refused"}},
+		{"non-critical failed", map[string]CheckerFunc{"db": healthy, "cache": failed},
+			map[string]bool{"db": true}, StatusUp, map[string]string{"cache": "connection refused"}},
+		{"critical panicked", map[string]CheckerFunc{"db": panicked},
+			map[string]bool{"db": true}, StatusDown, map[string]string{"db": "nil pointer"}},
+	}
+	for _, c := range cases {
+		reset()
+		for name, fn := range c.checkers {
+			Register(name, fn, Options{Critical: c.critical[name]})
+		}
+		r := Readiness(context.Background())
+		if r.Status != c.status || len(r.Checks) != len(c.checkers) {
+			t.Errorf("%s: got report %+v, want %s", c.name, r, c.status)
+			continue
+		}
+		for i, res := range r.Checks {
+			if i > 0 && r.Checks[i-1].Name > res.Name {
+				t.Errorf("%s: the checks are not sorted: %+v", c.name, r.Checks)
+			}
+			if res.Error != c.errors[res.Name] || (res.Error == "") != (res.Status == StatusUp) {
+				t.Errorf("%s: unexpected result %+v", c.name, res)
+			}
+		}
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	defer reset()
+	reset()
+
+	Register("disk", func(context.Context) error { return nil }, Options{Liveness: true, Critical: true})
+	Register("db", func(context.Context) error { return errors.New("down") }, Options{Critical: true})
+
+	if r := Liveness(context.Background()); r.Status != StatusUp || len(r.Checks) != 1 || r.Checks[0].Name != "disk" {
+		t.Errorf("unexpected liveness %+v", r)
+	}
+	if r := Readiness(context.Background()); r.Status != StatusDown || len(r.Checks) != 2 {
+		t.Errorf("unexpected readiness %+v", r)
+	}
+}
+
+func TestReadinessNotReady(t *testing.T) {
+	defer reset()
+	defer lifecycle.SetReady(true)
+	reset()
+
+	Register("db", func(context.Context) error { return nil }, Options{Critical: true})
+	lifecycle.SetReady(false)
+
+	r := Readiness(context.Background())
+	if r.Status != StatusDown || len(r.Checks) != 2 || r.Checks[0].Name != "lifecycle" {
+		t.Errorf("unexpected readiness %+v", r)
+	}
+	// the liveness is not affected
+	if r = Liveness(context.Background()); r.Status != StatusUp {
+		t.Errorf("unexpected liveness %+v", r)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	defer reset()
+	reset()
+	lifecycle.SetReady(true)
+
+	Register("slow", func(ctx context.Context) error {
+		select {
+		case <-time.After(time.Minute):
+		case <-ctx.Done():
+		}
+		return nil
+	}, Options{Critical: true, Timeout: 50 * time.Millisecond})
+	// the checker ignoring the context
+	Register("stuck", func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, Options{Timeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	r := Readiness(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("the checks take %v over the timeout", elapsed)
+	}
+	if r.Status != StatusDown {
+		t.Errorf("unexpected status %s", r.Status)
+	}
+	for _, res := range r.Checks {
+		if res.Status != StatusDown || res.Error != "check timeout after 50ms" {
+			t.Errorf("unexpected result %+v", res)
+		}
+	}
+}
+
+func TestCheckCache(t *testing.T) {
+	defer reset()
+	reset()
+	lifecycle.SetReady(true)
+
+	var calls int32
+	Register("db", func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}, Options{CacheTTL: 100 * time.Millisecond})
+
+	first := Readiness(context.Background()).Checks[0]
+	second := Readiness(context.Background()).Checks[0]
+	if n := atomic.LoadInt32(&calls); n != 1 || !second.CheckedAt.Equal(first.CheckedAt) {
+		t.Errorf("the cached result is not used, %d calls", n)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if third := Readiness(context.Background()).Checks[0]; third.CheckedAt.Equal(first.CheckedAt) {
+		t.Error("the expired result is used")
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("got %d calls after the ttl, want 2", n)
+	}
+
+	// the registration replaces the cached result
+	Register("db", func(context.Context) error { return errors.New("down") }, Options{Critical: true})
+	if r := Readiness(context.Background()); r.Status != StatusDown {
+		t.Errorf("unexpected report %+v", r)
+	}
+}
+
+func TestCheckCancelled(t *testing.T) {
+	defer reset()
+	reset()
+	lifecycle.SetReady(true)
+
+	var calls int32
+	Register("db", func(ctx context.Context) error {
+		// the first check is slower than the caller
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	}, Options{Critical: true, Timeout: 2 * time.Second, CacheTTL: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r := Readiness(ctx)
+	if res := r.Checks[0]; r.Status != StatusDown || res.Error != "check cancelled: context deadline exceeded" {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	// the result of the cancelled request isn't cached
+	if r = Readiness(context.Background()); r.Status != StatusUp {
+		t.Errorf("unexpected report %+v", r)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("got %d calls, want 2", n)
+	}
+}
@@ -0,0 +1,117 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zliang90/kingRest/pkg/log"
+)
+
+// Phase shutdown phase, the hooks run phase by phase in ascending order
+type Phase int
+
+const (
+	// stop accepting and drain the in-flight requests
+	PhaseHTTP Phase = iota
+	// stop the background workers, eg: config watcher
+	PhaseWorkers
+	// close the storages, eg: db pools
+	PhaseStorage
+)
+
+// Hook shutdown hook, it should return before the context is done
+type Hook func(ctx context.Context) error
+
+type hook struct {
+	phase Phase
+	name  string
+	fn    Hook
+}
+
+var (
+	hooks []hook
+	lock  = new(sync.Mutex)
+
+	// readiness, 1 means ready
+	ready int32
+
+	// delay before the http phase on shutdown, in nanoseconds
+	readinessDelay int64
+)
+
+// OnShutdown register a shutdown hook, the hooks of the same phase run
+// in the order of registration
+func OnShutdown(phase Phase, name string, fn Hook) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	hooks = append(hooks, hook{phase: phase, name: name, fn: fn})
+}
+
+// SetReady set the readiness of app
+func SetReady(r bool) {
+	var v int32
+	if r {
+		v = 1
+	}
+	atomic.StoreInt32(&ready, v)
+}
+
+// SetReadinessDelay set the delay between flipping the readiness and
+// running the hooks of PhaseHTTP, the load balancers stop routing the new
+// requests to the app meanwhile
+func SetReadinessDelay(d time.Duration) {
+	atomic.StoreInt64(&readinessDelay, int64(d))
+}
+
+// Ready whether the app is ready to serve
+func Ready() bool {
+	return atomic.LoadInt32(&ready) == 1
+}
+
+// Shutdown flip the readiness to not-ready, wait for the readiness delay or
+// the context is done, then run the shutdown hooks in order, every hook runs
+// even if the previous ones failed or the context is done
+func Shutdown(ctx context.Context) error {
+	SetReady(false)
+
+	if d := time.Duration(atomic.LoadInt64(&readinessDelay)); d > 0 {
+		log.Infof("not ready, wait %v for the load balancers", d)
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	lock.Lock()
+	hs := make([]hook, len(hooks))
+	copy(hs, hooks)
+	lock.Unlock()
+
+	sort.SliceStable(hs, func(i, j int) bool {
+		return hs[i].phase < hs[j].phase
+	})
+
+	var errs []string
+	for _, h := range hs {
+		start := time.Now()
+		log.Infof("shutdown %s", h.name)
+		if err := h.fn(ctx); err != nil {
+			log.Errorf("shutdown %s failed: %v", h.name, err)
+			errs = append(errs, fmt.Sprintf("%s: %v", h.name, err))
+			continue
+		}
+		log.Infof("shutdown %s done in %v", h.name, time.Since(start))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("shutdown failed, %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
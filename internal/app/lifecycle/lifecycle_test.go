@@ -0,0 +1,140 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// reset removes the hooks and the readiness delay of the previous test
+func reset() {
+	lock.Lock()
+	hooks = nil
+	lock.Unlock()
+	SetReadinessDelay(0)
+	SetReady(true)
+}
+
+func TestShutdownOrder(t *testing.T) {
+	reset()
+	defer reset()
+
+	var (
+		order []string
+		mu    sync.Mutex
+	)
+	record := func(name string) Hook {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+	OnShutdown(PhaseStorage, "db", record("db"))
+	OnShutdown(PhaseWorkers, "watcher", record("watcher"))
+	OnShutdown(PhaseHTTP, "web", record("web"))
+	OnShutdown(PhaseStorage, "cache", record("cache"))
+	OnShutdown(PhaseHTTP, "admin", func(ctx context.Context) error {
+		if Ready() {
+			t.Error("ready while draining")
+		}
+		return record("admin")(ctx)
+	})
+
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"web", "admin", "watcher", "db", "cache"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("got order %v, want %v", order, want)
+	}
+}
+
+func TestShutdownDrainDeadline(t *testing.T) {
+	reset()
+	defer reset()
+
+	var storageRun bool
+	OnShutdown(PhaseHTTP, "web", func(ctx context.Context) error {
+		// the in-flight requests never finish
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	OnShutdown(PhaseStorage, "db", func(context.Context) error {
+		storageRun = true
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := Shutdown(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("shutdown takes %v over the deadline", elapsed)
+	}
+	// the error makes the process exit with non-zero code
+	if err == nil || !strings.Contains(err.Error(), "web: "+context.DeadlineExceeded.Error()) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !storageRun {
+		t.Error("the hooks after the deadline are skipped")
+	}
+}
+
+func TestShutdownErrors(t *testing.T) {
+	reset()
+	defer reset()
+
+	OnShutdown(PhaseHTTP, "web", func(context.Context) error { return errors.New("close web") })
+	OnShutdown(PhaseWorkers, "watcher", func(context.Context) error { return nil })
+	OnShutdown(PhaseStorage, "db", func(context.Context) error { return errors.New("close db") })
+
+	err := Shutdown(context.Background())
+	if err == nil || err.Error() != "shutdown failed, web: close web; db: close db" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	reset()
+	if err = Shutdown(context.Background()); err != nil {
+		t.Errorf("unexpected error without hooks: %v", err)
+	}
+}
+
+func TestShutdownReadinessDelay(t *testing.T) {
+	reset()
+	defer reset()
+
+	delay := 100 * time.Millisecond
+	SetReadinessDelay(delay)
+
+	start := time.Now()
+	var waited time.Duration
+	OnShutdown(PhaseHTTP, "web", func(context.Context) error {
+		waited = time.Since(start)
+		return nil
+	})
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if waited < delay {
+		t.Errorf("the http phase starts after %v, want %v", waited, delay)
+	}
+
+	// the delay is cut by the deadline
+	SetReady(true)
+	SetReadinessDelay(time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if err := Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if waited > time.Second {
+		t.Errorf("the delay exceeds the deadline: %v", waited)
+	}
+}
@@ -30,6 +30,14 @@ func (b *Base) GetRequestId() string {
 	return ""
 }
 
+// GetClientSubject returns the subject of the verified client certificate
+func (b *Base) GetClientSubject() string {
+	if b.Ctx != nil {
+		return b.Ctx.GetString("Client-Subject")
+	}
+	return ""
+}
+
 func (b *Base) LogRequestIdPrefix() string {
 	reqId := b.GetRequestId()
 	if reqId == "" {
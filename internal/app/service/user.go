@@ -1,9 +1,15 @@
 package service
 
 import (
+	"errors"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
 	"github.com/zliang90/kingRest/internal/app/db"
+	"github.com/zliang90/kingRest/internal/restful/fields"
+	"github.com/zliang90/kingRest/internal/restful/filter"
+	"github.com/zliang90/kingRest/internal/restful/pagination"
 	"github.com/zliang90/kingRest/pkg/log"
 )
 
@@ -29,3 +35,62 @@ func (s User) DescribeUsers() ([]db.User, error) {
 	}
 	return users, nil
 }
+
+// UserFilter the fields of users can be filtered and sorted
+var UserFilter = &filter.Spec{
+	Fields: map[string]filter.Field{
+		"id":         {Ops: []string{filter.OpEq, filter.OpIn}},
+		"name":       {Ops: filter.StringOps, Sortable: true},
+		"created_at": {Type: filter.TypeTime, Ops: filter.CompareOps, Sortable: true},
+		"updated_at": {Type: filter.TypeTime, Ops: filter.CompareOps, Sortable: true},
+	},
+	DefaultSort: "-created_at",
+}
+
+// UserFields the fields of users can be selected, the password is never responded
+var UserFields = &fields.Spec{
+	Fields: []string{"id", "name", "created_at", "updated_at"},
+}
+
+// ListUsers returns the users of the page matching the filter, see UserFilter,
+// the relations of the selection are preloaded
+func (s User) ListUsers(p *pagination.Params, q *filter.Query, sel *fields.Selection) ([]db.User, *pagination.Page, error) {
+	users := make([]db.User, 0)
+
+	log.Infof("%s, list users, offset: %d, size: %d", s.LogRequestIdPrefix(), p.Offset, p.Size)
+	page, err := p.Find(sel.Preload(q.Apply(s.db.Model(&db.User{}))), &users)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, page, nil
+}
+
+// ErrUserModified the user has been modified concurrently since it's read
+var ErrUserModified = errors.New("user has been modified")
+
+// GetUser returns the user of the id, gorm.ErrRecordNotFound if it doesn't exist
+func (s User) GetUser(id string) (*db.User, error) {
+	user := new(db.User)
+
+	log.Infof("%s, get user %s", s.LogRequestIdPrefix(), id)
+	if err := s.db.Where("id = ?", id).First(user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateUser updates the name of the user only if it's not modified since it's read,
+// ErrUserModified if the updated_at has been changed by the others
+func (s User) UpdateUser(user *db.User, name string) error {
+	log.Infof("%s, update user %s", s.LogRequestIdPrefix(), user.Id)
+	result := s.db.Model(&db.User{}).
+		Where("id = ? AND updated_at = ?", user.Id, user.UpdatedAt).
+		Updates(map[string]interface{}{"name": name, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserModified
+	}
+	return nil
+}
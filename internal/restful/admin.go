@@ -0,0 +1,143 @@
+package restful
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/pprof"
+	"github.com/gin-gonic/gin"
+	"github.com/zliang90/kingRest/internal/app/conf"
+	"github.com/zliang90/kingRest/internal/restful/api"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+	"github.com/zliang90/kingRest/internal/restful/validator"
+	"github.com/zliang90/kingRest/pkg/log"
+)
+
+// AdminServer serving pprof, health, metrics, the route table and log level
+// controls on a separate address
+type AdminServer struct {
+	cf conf.AdminServer
+
+	// the public restful api server
+	public *Server
+
+	// http server
+	srv *http.Server
+}
+
+// NewAdmin returns nil if the admin server is not configured
+func NewAdmin(cf *conf.Config, public *Server) *AdminServer {
+	if cf.AdminServer == nil {
+		return nil
+	}
+	return &AdminServer{
+		cf:     *cf.AdminServer,
+		public: public,
+	}
+}
+
+// Start listening on the admin address and serving in background
+func (s *AdminServer) Start() error {
+	l, err := listen(listenerAdmin, s.cf.Addr, s.cf.SocketMode)
+	if err != nil {
+		return err
+	}
+	log.Infof("serving admin api on: %s", l.Addr())
+
+	s.srv = &http.Server{
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := s.srv.Serve(l); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+	return nil
+}
+
+// Shutdown stop the admin server
+func (s *AdminServer) Shutdown(ctx context.Context) error {
+	if s.srv == nil {
+		return nil
+	}
+	log.Info("stopping admin api")
+	if err := s.srv.Shutdown(ctx); err != nil {
+		s.srv.Close()
+		return fmt.Errorf("drain admin api, %v", err)
+	}
+	return nil
+}
+
+func (s *AdminServer) handler() http.Handler {
+	engine := gin.New()
+	engine.Use(handlerRequestId(), handlerRecovery())
+
+	engine.GET("/healthz", api.Healthz)
+	engine.GET("/readyz", api.Readyz)
+	engine.GET("/metrics", func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4")
+		metrics.writeTo(c.Writer)
+	})
+	engine.GET("/routes", s.routes)
+	engine.GET("/loglevel", getLogLevel)
+	engine.PUT("/loglevel", setLogLevel)
+	engine.DELETE("/loglevel", clearLogLevel)
+	pprof.Register(engine)
+
+	engine.NoRoute(func(c *gin.Context) {
+		api.Failure(c, errors.NewNotFound(
+			fmt.Sprintf("%s '%s'", c.Request.Method, c.Request.URL)))
+	})
+	return engine
+}
+
+func (s *AdminServer) routes(c *gin.Context) {
+	type route struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+	}
+	routes := make([]route, 0)
+	for _, r := range s.public.Handler().(*gin.Engine).Routes() {
+		routes = append(routes, route{r.Method, r.Path})
+	}
+	api.SuccessWithTotal(c, routes, len(routes))
+}
+
+type logLevel struct {
+	Level string `json:"level" validate:"required"`
+
+	// whether it's set by the admin api, it's kept on config reloading until cleared
+	Override bool `json:"override"`
+}
+
+func getLogLevel(c *gin.Context) {
+	api.Success(c, logLevel{Level: log.GetLevel().String(), Override: conf.LogLevelOverridden()})
+}
+
+func setLogLevel(c *gin.Context) {
+	var req logLevel
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Failure(c, validator.BadRequest(err))
+		return
+	}
+	level, err := log.ParseLevel(req.Level)
+	if err != nil {
+		api.Failure(c, errors.NewBadRequest(err))
+		return
+	}
+	conf.OverrideLogLevel(level.String())
+	log.Infof("log level is changed to %s by admin api", level)
+
+	api.Success(c, logLevel{Level: level.String(), Override: true})
+}
+
+// clearLogLevel clears the log level set by the admin api, the configured one is restored
+func clearLogLevel(c *gin.Context) {
+	conf.OverrideLogLevel("")
+	log.Infof("log level is restored to %s by admin api", log.GetLevel())
+
+	api.Success(c, logLevel{Level: log.GetLevel().String()})
+}
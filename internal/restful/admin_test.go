@@ -0,0 +1,105 @@
+package restful
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zliang90/kingRest/internal/app/conf"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+	"github.com/zliang90/kingRest/pkg/log"
+)
+
+// adminResponse the response of the admin api
+type adminResponse struct {
+	Code  int64           `json:"code"`
+	Data  json.RawMessage `json:"data"`
+	Total int             `json:"total"`
+}
+
+func serveAdmin(t *testing.T, s *AdminServer, method, path, body string) (int, adminResponse) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, req)
+
+	var resp adminResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s %s: %v, body %s", method, path, err, w.Body.String())
+	}
+	return w.Code, resp
+}
+
+func TestAdminLogLevel(t *testing.T) {
+	if err := errors.LoadMessages("../../config/errors.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	defer log.SetLevel(log.GetLevel())
+	defer conf.OverrideLogLevel("")
+
+	s := &AdminServer{}
+	cases := []struct {
+		method, body string
+		status       int
+		code         int64
+		level        string
+		override     bool
+	}{
+		{http.MethodPut, `{"level":"warning"}`, http.StatusOK, 0, "WARNING", true},
+		{http.MethodGet, "", http.StatusOK, 0, "WARNING", true},
+		{http.MethodPut, `{"level":"verbose"}`, http.StatusBadRequest, 1000400, "", false},
+		{http.MethodPut, `{}`, http.StatusBadRequest, 1000400, "", false},
+		{http.MethodPut, `{"level":`, http.StatusBadRequest, 1000400, "", false},
+		// the invalid requests don't change the level
+		{http.MethodGet, "", http.StatusOK, 0, "WARNING", true},
+		{http.MethodDelete, "", http.StatusOK, 0, "", false},
+		{http.MethodGet, "", http.StatusOK, 0, "", false},
+	}
+	for _, c := range cases {
+		status, resp := serveAdmin(t, s, c.method, "/loglevel", c.body)
+		if status != c.status || resp.Code != c.code {
+			t.Errorf("%s %s: got %d %d, want %d %d", c.method, c.body, status, resp.Code, c.status, c.code)
+			continue
+		}
+		if status != http.StatusOK {
+			continue
+		}
+		var got logLevel
+		if err := json.Unmarshal(resp.Data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Override != c.override || c.level != "" && got.Level != c.level {
+			t.Errorf("%s %s: got %+v, want %s %v", c.method, c.body, got, c.level, c.override)
+		}
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	public := gin.New()
+	public.GET("/api/v1/users", func(*gin.Context) {})
+	public.PATCH("/api/v1/users/:id", func(*gin.Context) {})
+	s := &AdminServer{public: &Server{r: public}}
+
+	status, resp := serveAdmin(t, s, http.MethodGet, "/routes", "")
+	if status != http.StatusOK || resp.Total != 2 {
+		t.Fatalf("got %d, total %d", status, resp.Total)
+	}
+	var routes []struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+	}
+	if err := json.Unmarshal(resp.Data, &routes); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"/api/v1/users": http.MethodGet, "/api/v1/users/:id": http.MethodPatch}
+	for _, r := range routes {
+		if want[r.Path] != r.Method {
+			t.Errorf("unexpected route %s %s", r.Method, r.Path)
+		}
+	}
+}
@@ -1,10 +1,14 @@
 package api
 
 import (
+	"crypto/x509"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/zliang90/kingRest/internal/restful/errors"
+	"github.com/zliang90/kingRest/internal/restful/pagination"
+	"github.com/zliang90/kingRest/pkg/log"
 )
 
 const (
@@ -16,14 +20,38 @@ type Response struct {
 	Code      int64       `json:"code"`
 	Data      interface{} `json:"data,omitempty"`
 	Total     int         `json:"total,omitempty"`
+
+	// pagination of the list data, see SuccessWithPage
+	Page       int    `json:"page,omitempty"`
+	Size       int    `json:"size,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 func GetRequestId(ctx *gin.Context) string {
 	return ctx.GetString("Request-Id")
 }
 
+// GetClientSubject returns the subject of the verified client certificate,
+// it's empty if the client is not verified by mutual tls
+func GetClientSubject(ctx *gin.Context) string {
+	return ctx.GetString("Client-Subject")
+}
+
+// GetClientCert returns the verified client certificate of mutual tls
+func GetClientCert(ctx *gin.Context) *x509.Certificate {
+	if v, ok := ctx.Get("Client-Cert"); ok {
+		if cert, ok := v.(*x509.Certificate); ok {
+			return cert
+		}
+	}
+	return nil
+}
+
+// SuccessWithTotal responds the list data in the content type negotiated by Accept,
+// see RegisterRenderer
 func SuccessWithTotal(ctx *gin.Context, data interface{}, total int) {
-	ctx.JSON(http.StatusOK, Response{
+	respond(ctx, http.StatusOK, Response{
 		RequestId: GetRequestId(ctx),
 		Code:      SuccessOK,
 		Data:      data,
@@ -31,21 +59,96 @@ func SuccessWithTotal(ctx *gin.Context, data interface{}, total int) {
 	})
 }
 
+// SuccessWithPage responds the list data of the page, with the Link header
+// of the first/prev/next/last pages
+func SuccessWithPage(ctx *gin.Context, data interface{}, page *pagination.Page) {
+	if link := page.Link(); link != "" {
+		ctx.Header("Link", link)
+	}
+	respond(ctx, http.StatusOK, Response{
+		RequestId:  GetRequestId(ctx),
+		Code:       SuccessOK,
+		Data:       data,
+		Total:      page.Total,
+		Page:       page.Page,
+		Size:       page.Size,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	})
+}
+
+// Success responds the data in the content type negotiated by Accept,
+// see RegisterRenderer
 func Success(ctx *gin.Context, data interface{}) {
-	ctx.JSON(http.StatusOK, Response{
+	respond(ctx, http.StatusOK, Response{
 		RequestId: GetRequestId(ctx),
 		Code:      SuccessOK,
 		Data:      data,
 	})
 }
 
+// Failure responds the api error in the content type negotiated by Accept,
+// or json if nothing matches
 func Failure(ctx *gin.Context, err interface{}) {
-	if e, ok := err.(*errors.APIError); ok {
-		e.RequestId = GetRequestId(ctx)
-		ctx.AbortWithStatusJSON(http.StatusOK, e)
+	failure(ctx, err, true)
+}
+
+func failure(ctx *gin.Context, err interface{}, negotiated bool) {
+	e, ok := err.(*errors.APIError)
+	if !ok {
+		e = errors.NewUnknownError(err)
+	}
+	e.RequestId = GetRequestId(ctx)
+	ctx.Header("Vary", vary)
+	e.Localize(ctx.GetHeader("Accept-Language"))
+	logFailure(ctx, e)
+
+	// the cause chain and stack for debugging
+	if errors.GetSettings().Env != "prod" && e.Details == nil {
+		e.Details = e.Debug()
+	}
+
+	if acceptProblem(ctx) {
+		ctx.Header("Content-Type", errors.ProblemContentType+"; charset=utf-8")
+		ctx.AbortWithStatusJSON(e.HTTPStatus(), e.Problem(ctx.Request.URL.RequestURI()))
 		return
 	}
-	err1 := errors.NewAPIError("UNKNOWN_ERROR", errors.Params{"error": err})
-	err1.RequestId = GetRequestId(ctx)
-	ctx.AbortWithStatusJSON(http.StatusOK, err1)
+
+	r, ok := jsonRenderer, false
+	if negotiated {
+		r, ok = negotiate(ctx, e)
+	}
+	if !ok {
+		r = jsonRenderer
+	}
+	ctx.Abort()
+	r.Render(ctx, e.HTTPStatus(), r.ContentType, e)
+}
+
+// acceptProblem whether to render api errors as problem details,
+// by the Accept header or the configured format
+func acceptProblem(ctx *gin.Context) bool {
+	if errors.GetSettings().Format == errors.FormatProblem {
+		return true
+	}
+	return strings.Contains(ctx.GetHeader("Accept"), errors.ProblemContentType)
+}
+
+// logFailure logs the api error with the cause chain and the stack,
+// the server errors at error level, the others at info level so that they're
+// visible in prod
+func logFailure(ctx *gin.Context, e *errors.APIError) {
+	logf := log.Infof
+	if e.Status >= http.StatusInternalServerError {
+		logf = log.Errorf
+	}
+	logf("reqId: %s, \"%s %s\" api error %s(%d): %s, causes: [%s]\n\t%s",
+		e.RequestId,
+		ctx.Request.Method,
+		ctx.Request.URL.RequestURI(),
+		e.Key,
+		e.Code,
+		e.Message,
+		strings.Join(e.Causes(), " <- "),
+		strings.Join(e.Stack(), "\n\t"))
 }
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+)
+
+func TestFailureProblem(t *testing.T) {
+	if err := errors.LoadMessages("../../../config/errors.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	defer errors.SetFormat("", "")
+	defer errors.SetLegacyStatus(false)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/users/:id", func(ctx *gin.Context) {
+		Failure(ctx, errors.NewAPIError(errors.KeyNotFound, errors.Params{"resource": "user"}))
+	})
+
+	cases := []struct {
+		name        string
+		format      string
+		legacy      bool
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"default", "", false, "", http.StatusNotFound, "application/json"},
+		{"json", "", false, "application/json", http.StatusNotFound, "application/json"},
+		{"problem", "", false, "application/problem+json", http.StatusNotFound, errors.ProblemContentType},
+		{"problem or json", "", false, "application/problem+json, application/json;q=0.9", http.StatusNotFound, errors.ProblemContentType},
+		{"xml", "", false, "application/xml", http.StatusNotFound, "application/xml"},
+		{"configured problem", errors.FormatProblem, false, "", http.StatusNotFound, errors.ProblemContentType},
+		{"configured problem over xml", errors.FormatProblem, false, "application/xml", http.StatusNotFound, errors.ProblemContentType},
+		{"legacy", "", true, "", http.StatusOK, "application/json"},
+		{"legacy problem", "", true, "application/problem+json", http.StatusOK, errors.ProblemContentType},
+	}
+	for _, c := range cases {
+		errors.SetFormat(c.format, "")
+		errors.SetLegacyStatus(c.legacy)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		if w.Code != c.status {
+			t.Errorf("%s: got status %d, want %d", c.name, w.Code, c.status)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, c.contentType) {
+			t.Errorf("%s: got content type %s, want %s", c.name, ct, c.contentType)
+		}
+		if c.contentType != errors.ProblemContentType {
+			continue
+		}
+		var p errors.Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if p.Status != c.status || p.Code != 1000404 || p.Instance != "/users/1" || p.Title == "" {
+			t.Errorf("%s: unexpected problem %+v", c.name, p)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+	"github.com/zliang90/kingRest/internal/restful/fields"
+)
+
+// ETag returns the strong entity tag of the value in json, eg: "3f2a...",
+// encoding/json is used for the sorted map keys
+func ETag(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return bodyETag(data), nil
+}
+
+// VersionETag returns the strong entity tag of the version of the resource by
+// its updated time, eg: db.BaseModel.UpdatedAt, without marshaling the data like
+// ETagOf: the version is identified by the request path and the updated time, the
+// representation by the selected fields, the negotiated content type and the locale
+func VersionETag(ctx *gin.Context, data interface{}, updatedAt time.Time) string {
+	r, ok := negotiate(ctx, Response{Code: SuccessOK, Data: data})
+	if !ok {
+		r = jsonRenderer
+	}
+	return bodyETag([]byte(strings.Join([]string{
+		ctx.Request.URL.Path,
+		strconv.FormatInt(updatedAt.UnixNano(), 10),
+		ctx.Query(fields.ParamFields),
+		r.ContentType,
+		errors.Locale(ctx.GetHeader("Accept-Language")),
+	}, "\n")))
+}
+
+// ETagOf returns the strong entity tag of the representation of the data as
+// responded by Success: the request id is excluded, the fields are selected
+// by ?fields=, and it's different by the content type negotiated by Accept
+// and the locale of Accept-Language
+func ETagOf(ctx *gin.Context, data interface{}) (string, error) {
+	v, err := selected(ctx, Response{Code: SuccessOK, Data: data})
+	if err != nil {
+		return "", err
+	}
+	r, ok := negotiate(ctx, v)
+	if !ok {
+		r = jsonRenderer
+	}
+	return representationETag(ctx, r.ContentType, v)
+}
+
+// NotModified sets the ETag header, and responds 304 if it matches
+// the If-None-Match of the request, the clients must revalidate every time
+func NotModified(ctx *gin.Context, etag string) bool {
+	return NotModifiedSince(ctx, etag, time.Time{})
+}
+
+// NotModifiedSince sets the ETag and Last-Modified headers, and responds 304 if
+// the If-None-Match, or If-Modified-Since without If-None-Match, is fresh.
+// the zero etag or last modified time is ignored
+func NotModifiedSince(ctx *gin.Context, etag string, lastModified time.Time) bool {
+	setValidators(ctx, etag, lastModified)
+	ctx.Header("Cache-Control", "no-cache")
+
+	if !fresh(ctx.Request, etag, lastModified) {
+		return false
+	}
+	ctx.AbortWithStatus(http.StatusNotModified)
+	return true
+}
+
+// PreconditionFailed checks the If-Match, or If-Unmodified-Since without If-Match,
+// of PUT/PATCH/DELETE against the current version of the resource, and responds
+// 412 PRECONDITION_FAILED if it has been modified, see HandlerPrecondition
+func PreconditionFailed(ctx *gin.Context, etag string, lastModified time.Time) bool {
+	r := ctx.Request
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		if matchStrongETag(ifMatch, etag) {
+			return false
+		}
+		ctx.Abort()
+		Failure(ctx, errors.NewPreconditionFailed("If-Match: "+ifMatch))
+		return true
+	}
+
+	since := r.Header.Get("If-Unmodified-Since")
+	if since == "" || lastModified.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(since)
+	if err != nil || !lastModified.Truncate(time.Second).After(t) {
+		return false
+	}
+	ctx.Abort()
+	Failure(ctx, errors.NewPreconditionFailed("If-Unmodified-Since: "+since))
+	return true
+}
+
+// Version returns the ETag and the last modified time of the current version
+// of the resource of the request, eg: ETagOf the resource and its UpdatedAt
+type Version func(ctx *gin.Context) (etag string, lastModified time.Time, err error)
+
+// HandlerPrecondition enforces If-Match and If-Unmodified-Since of PUT, PATCH and
+// DELETE against the current version of the resource, the request is aborted with
+// 428 PRECONDITION_REQUIRED without them, or 412 PRECONDITION_FAILED if the resource
+// has been modified since the client read it, it's applied to the routes of the
+// resources updated with optimistic concurrency, eg:
+//
+//	v1.PATCH("/users/:id", api.HandlerPrecondition(apiV1.UserVersion), apiV1.UpdateUser)
+func HandlerPrecondition(version Version) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		switch ctx.Request.Method {
+		case http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			ctx.Next()
+			return
+		}
+		if ctx.GetHeader("If-Match") == "" && ctx.GetHeader("If-Unmodified-Since") == "" {
+			ctx.Abort()
+			Failure(ctx, errors.NewPreconditionRequired("If-Match or If-Unmodified-Since"))
+			return
+		}
+
+		etag, lastModified, err := version(ctx)
+		if err != nil {
+			ctx.Abort()
+			Failure(ctx, err)
+			return
+		}
+		if PreconditionFailed(ctx, etag, lastModified) {
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// HandlerETag buffers the 200 responses of GET and HEAD, sets the strong ETag
+// of the body if the handler doesn't set it, and responds 304 if the request
+// is fresh. the responses of Success are validated by ETagOf the data instead
+// of the body, which has the request id, see NotModifiedSince for the resources
+func HandlerETag() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead {
+			ctx.Next()
+			return
+		}
+
+		w := &bufferedWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = w
+		ctx.Next()
+		ctx.Writer = w.ResponseWriter
+
+		// responded, eg: 304 by NotModified
+		if w.Written() {
+			_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+			return
+		}
+
+		header := w.Header()
+		if w.Status() == http.StatusOK && w.buf.Len() > 0 {
+			etag := header.Get("ETag")
+			if etag == "" {
+				etag = bodyETag(w.buf.Bytes())
+				header.Set("ETag", etag)
+			}
+			lastModified, _ := http.ParseTime(header.Get("Last-Modified"))
+			if fresh(ctx.Request, etag, lastModified) {
+				for _, h := range []string{"Content-Type", "Content-Length"} {
+					header.Del(h)
+				}
+				w.WriteHeader(http.StatusNotModified)
+				w.WriteHeaderNow()
+				return
+			}
+		}
+		w.WriteHeaderNow()
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+}
+
+// bufferedWriter holds the body until the handlers are done
+type bufferedWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.buf.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.buf.WriteString(s)
+}
+
+// notModified sets the ETag of the representation if the handler doesn't set it,
+// and responds 304 if the GET or HEAD is fresh
+func notModified(ctx *gin.Context, contentType string, v interface{}) bool {
+	header := ctx.Writer.Header()
+	etag := header.Get("ETag")
+	if etag == "" {
+		var err error
+		if etag, err = representationETag(ctx, contentType, v); err != nil {
+			return false
+		}
+		header.Set("ETag", etag)
+	}
+
+	if m := ctx.Request.Method; m != http.MethodGet && m != http.MethodHead {
+		return false
+	}
+	lastModified, _ := http.ParseTime(header.Get("Last-Modified"))
+	if !fresh(ctx.Request, etag, lastModified) {
+		return false
+	}
+	ctx.AbortWithStatus(http.StatusNotModified)
+	return true
+}
+
+// representationETag returns the ETag of the response without the request id,
+// in the content type and the locale of the request
+func representationETag(ctx *gin.Context, contentType string, v interface{}) (string, error) {
+	if res, ok := v.(Response); ok {
+		res.RequestId = ""
+		v = res
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	data = append(data, "\n"+contentType+"\n"+errors.Locale(ctx.GetHeader("Accept-Language"))...)
+	return bodyETag(data), nil
+}
+
+func bodyETag(body []byte) string {
+	sum := sha1.Sum(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func setValidators(ctx *gin.Context, etag string, lastModified time.Time) {
+	if etag != "" {
+		ctx.Header("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		ctx.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// fresh reports whether the cached response of the client is still valid,
+// If-Modified-Since is ignored if If-None-Match is present
+func fresh(r *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etag != "" && matchETag(ifNoneMatch, etag)
+	}
+	since := r.Header.Get("If-Modified-Since")
+	if since == "" || lastModified.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(since)
+	return err == nil && !lastModified.Truncate(time.Second).After(t)
+}
+
+// matchETag reports whether the etag is in the list of the header,
+// the weak comparison is used, eg: W/"abc" matches "abc"
+func matchETag(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchStrongETag reports whether the etag is in the list of If-Match,
+// the strong comparison is used, the weak tags never match
+func matchStrongETag(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" && etag != "" {
+			return true
+		}
+		if etag != "" && !strings.HasPrefix(etag, "W/") && tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+	"github.com/zliang90/kingRest/pkg/util/uuid"
+)
+
+func TestHandlerETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/", HandlerETag(), func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "hello")
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != "hello" || etag == "" {
+		t.Fatalf("got %d %q, etag %q", w.Code, w.Body.String(), etag)
+	}
+
+	for header, status := range map[string]int{etag: http.StatusNotModified, "W/" + etag: http.StatusNotModified, `"other"`: http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", header)
+		w = httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Errorf("If-None-Match %s: got %d, want %d", header, w.Code, status)
+		}
+		if status == http.StatusNotModified && w.Body.Len() > 0 {
+			t.Errorf("If-None-Match %s: unexpected body %q", header, w.Body.String())
+		}
+	}
+}
+
+func TestSuccessETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/", HandlerETag(), func(ctx *gin.Context) {
+		// a new request id of every request
+		ctx.Set("Request-Id", uuid.New())
+		data := map[string]string{"name": "admin"}
+		etag, err := ETagOf(ctx, data)
+		Success(ctx, data)
+		if got := ctx.Writer.Header().Get("ETag"); err != nil || got != etag {
+			t.Errorf("ETagOf = %s, %v, responded %s", etag, err, got)
+		}
+	})
+	get := func(header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for k := range header {
+			req.Header.Set(k, header.Get(k))
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	first, second := get(nil), get(nil)
+	etag := first.Header().Get("ETag")
+	if etag == "" || second.Header().Get("ETag") != etag {
+		t.Fatalf("etag %q, then %q", etag, second.Header().Get("ETag"))
+	}
+	if w := get(http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified || w.Body.Len() > 0 {
+		t.Errorf("If-None-Match: got %d %q", w.Code, w.Body.String())
+	}
+
+	// the other representation
+	if w := get(http.Header{"Accept": {"application/xml"}}); w.Header().Get("ETag") == etag {
+		t.Errorf("the same etag %s of json and xml", etag)
+	}
+	if w := get(http.Header{"Accept": {"application/xml"}, "If-None-Match": {etag}}); w.Code != http.StatusOK {
+		t.Errorf("If-None-Match of json in xml: got %d", w.Code)
+	}
+}
+
+func TestVersionETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	updated := time.Date(2020, 1, 2, 3, 4, 5, 600, time.UTC)
+	etagOf := func(method, target string, header http.Header, updatedAt time.Time) string {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(method, target, nil)
+		for k := range header {
+			ctx.Request.Header.Set(k, header.Get(k))
+		}
+		ctx.Set("Request-Id", uuid.New())
+		return VersionETag(ctx, map[string]string{"name": "admin"}, updatedAt)
+	}
+
+	etag := etagOf(http.MethodGet, "/users/1", nil, updated)
+	if etag == "" || strings.HasPrefix(etag, "W/") {
+		t.Fatalf("got %q, want a strong etag", etag)
+	}
+	// the same version of another request, eg: If-Match of PATCH
+	for _, method := range []string{http.MethodGet, http.MethodPatch} {
+		if got := etagOf(method, "/users/1", nil, updated); got != etag {
+			t.Errorf("%s: got %s, want %s", method, got, etag)
+		}
+	}
+
+	cases := []struct {
+		name      string
+		target    string
+		header    http.Header
+		updatedAt time.Time
+	}{
+		{"updated", "/users/1", nil, updated.Add(time.Millisecond)},
+		{"other resource", "/users/2", nil, updated},
+		{"fields", "/users/1?fields=id", nil, updated},
+		{"xml", "/users/1", http.Header{"Accept": {"application/xml"}}, updated},
+		{"locale", "/users/1", http.Header{"Accept-Language": {"en-US"}}, updated},
+	}
+	for _, c := range cases {
+		if got := etagOf(http.MethodGet, c.target, c.header, c.updatedAt); got == etag {
+			t.Errorf("%s: the same etag %s", c.name, got)
+		}
+	}
+}
+
+func TestHandlerPrecondition(t *testing.T) {
+	if err := errors.LoadMessages("../../../config/errors.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	version := func(ctx *gin.Context) (string, time.Time, error) {
+		return `"v2"`, modified, nil
+	}
+	engine := gin.New()
+	engine.Use(HandlerPrecondition(version))
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		engine.Handle(method, "/", func(ctx *gin.Context) {
+			ctx.Status(http.StatusNoContent)
+		})
+	}
+
+	cases := []struct {
+		method, header, value string
+		status                int
+	}{
+		{http.MethodPatch, "", "", http.StatusPreconditionRequired},
+		{http.MethodGet, "", "", http.StatusNoContent},
+		{http.MethodPatch, "If-Match", `"v2"`, http.StatusNoContent},
+		{http.MethodPut, "If-Match", `"v1", "v2"`, http.StatusNoContent},
+		{http.MethodDelete, "If-Match", `*`, http.StatusNoContent},
+		{http.MethodPatch, "If-Match", `"v1"`, http.StatusPreconditionFailed},
+		{http.MethodDelete, "If-Match", `W/"v2"`, http.StatusPreconditionFailed},
+		{http.MethodPut, "If-Unmodified-Since", "Thu, 02 Jan 2020 03:04:05 GMT", http.StatusNoContent},
+		{http.MethodPut, "If-Unmodified-Since", "Thu, 02 Jan 2020 03:04:04 GMT", http.StatusPreconditionFailed},
+		{http.MethodGet, "If-Match", `"v1"`, http.StatusNoContent},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, "/", nil)
+		if c.header != "" {
+			req.Header.Set(c.header, c.value)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s %s: %s: got %d, want %d", c.method, c.header, c.value, w.Code, c.status)
+		}
+		if c.status == http.StatusPreconditionFailed && !strings.Contains(w.Body.String(), `"code":1000412`) {
+			t.Errorf("%s %s: %s: got %s", c.method, c.header, c.value, w.Body.String())
+		}
+		if c.status == http.StatusPreconditionRequired && !strings.Contains(w.Body.String(), `"code":1000428`) {
+			t.Errorf("%s: got %s", c.method, w.Body.String())
+		}
+	}
+}
+
+func TestFresh(t *testing.T) {
+	modified := time.Date(2020, 1, 2, 3, 4, 5, 600, time.UTC)
+	cases := []struct {
+		header, value string
+		fresh         bool
+	}{
+		{"If-Modified-Since", "Thu, 02 Jan 2020 03:04:05 GMT", true},
+		{"If-Modified-Since", "Thu, 02 Jan 2020 03:04:04 GMT", false},
+		{"If-Modified-Since", "invalid", false},
+		{"If-None-Match", `"v1", "v2"`, true},
+		{"If-None-Match", `"v3"`, false},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(c.header, c.value)
+		if got := fresh(req, `"v2"`, modified); got != c.fresh {
+			t.Errorf("%s: %s = %v, want %v", c.header, c.value, got, c.fresh)
+		}
+	}
+}
+
+func TestMatchStrongETag(t *testing.T) {
+	cases := []struct {
+		header, etag string
+		match        bool
+	}{
+		{`"a", "b"`, `"b"`, true},
+		{`*`, `"a"`, true},
+		{`W/"a"`, `"a"`, false},
+		{`"a"`, `W/"a"`, false},
+		{`"a"`, `"b"`, false},
+	}
+	for _, c := range cases {
+		if got := matchStrongETag(c.header, c.etag); got != c.match {
+			t.Errorf("matchStrongETag(%s, %s) = %v", c.header, c.etag, got)
+		}
+	}
+}
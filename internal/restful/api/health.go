@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zliang90/kingRest/internal/app/health"
+)
+
+// Healthz liveness of the process
+func Healthz(ctx *gin.Context) {
+	renderReport(ctx, health.Liveness(ctx.Request.Context()))
+}
+
+// Readyz readiness of the app and its dependencies
+func Readyz(ctx *gin.Context) {
+	renderReport(ctx, health.Readiness(ctx.Request.Context()))
+}
+
+func renderReport(ctx *gin.Context, r health.Report) {
+	status := http.StatusOK
+	if r.Status != health.StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, r)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zliang90/kingRest/internal/app/health"
+	"github.com/zliang90/kingRest/internal/app/lifecycle"
+)
+
+func TestReadyz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/healthz", Healthz)
+	engine.GET("/readyz", Readyz)
+
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+	defer health.Unregister("test-db")
+	defer health.Unregister("test-cache")
+	defer lifecycle.SetReady(true)
+
+	cases := []struct {
+		name    string
+		ready   bool
+		db      error
+		cache   error
+		healthz int
+		readyz  int
+	}{
+		{"healthy", true, nil, nil, http.StatusOK, http.StatusOK},
+		{"critical failed", true, errors.New("down"), nil, http.StatusOK, http.StatusServiceUnavailable},
+		{"non-critical failed", true, nil, errors.New("down"), http.StatusOK, http.StatusOK},
+		{"shutting down", false, nil, nil, http.StatusOK, http.StatusServiceUnavailable},
+	}
+	for _, c := range cases {
+		lifecycle.SetReady(c.ready)
+		dbErr, cacheErr := c.db, c.cache
+		health.Register("test-db", func(context.Context) error { return dbErr }, health.Options{Critical: true})
+		health.Register("test-cache", func(context.Context) error { return cacheErr }, health.Options{})
+
+		if code := get("/healthz"); code != c.healthz {
+			t.Errorf("%s: got healthz %d, want %d", c.name, code, c.healthz)
+		}
+		if code := get("/readyz"); code != c.readyz {
+			t.Errorf("%s: got readyz %d, want %d", c.name, code, c.readyz)
+		}
+	}
+}
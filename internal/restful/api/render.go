@@ -0,0 +1,215 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+	"github.com/zliang90/kingRest/internal/restful/fields"
+)
+
+// Renderer renders the response in the content type negotiated by Accept
+type Renderer struct {
+	// media type, eg: application/json
+	ContentType string
+
+	// whether the value can be rendered, eg: csv only renders the list data,
+	// nil means any value
+	Accepts func(v interface{}) bool
+
+	// render the value, the content type is the negotiated one
+	Render func(ctx *gin.Context, status int, contentType string, v interface{})
+}
+
+var (
+	jsonRenderer = Renderer{ContentType: "application/json", Render: renderJSON}
+
+	// the request headers the responses are negotiated by
+	vary = "Accept, Accept-Language"
+
+	// the registered renderers, the first one is the default
+	renderers     []Renderer
+	renderersLock = new(sync.RWMutex)
+)
+
+func init() {
+	RegisterRenderer(jsonRenderer)
+	RegisterRenderer(Renderer{ContentType: "application/xml", Render: renderXML})
+	RegisterRenderer(Renderer{ContentType: "text/xml", Render: renderXML})
+	RegisterRenderer(Renderer{ContentType: "application/x-yaml", Render: renderYAML})
+	RegisterRenderer(Renderer{ContentType: "application/yaml", Render: renderYAML})
+	RegisterRenderer(Renderer{ContentType: "text/yaml", Render: renderYAML})
+	RegisterRenderer(Renderer{ContentType: "application/msgpack", Render: renderMsgPack})
+	RegisterRenderer(Renderer{ContentType: "application/x-msgpack", Render: renderMsgPack})
+	RegisterRenderer(Renderer{ContentType: "text/csv", Accepts: isList, Render: renderCSV})
+}
+
+// RegisterRenderer registers the renderer of the content type,
+// the one registered before is replaced
+func RegisterRenderer(r Renderer) {
+	renderersLock.Lock()
+	defer renderersLock.Unlock()
+
+	for i := range renderers {
+		if strings.EqualFold(renderers[i].ContentType, r.ContentType) {
+			renderers[i] = r
+			return
+		}
+	}
+	renderers = append(renderers, r)
+}
+
+// negotiate returns the renderer of the most preferred media type in Accept
+// which can render the value, the default one if Accept is empty
+func negotiate(ctx *gin.Context, v interface{}) (Renderer, bool) {
+	renderersLock.RLock()
+	defer renderersLock.RUnlock()
+
+	accept := ctx.GetHeader("Accept")
+	if strings.TrimSpace(accept) == "" {
+		accept = "*/*"
+	}
+	ranges := parseAccept(accept)
+
+	// the browsers accept html first, and xml before */*, eg:
+	// "text/html,application/xml;q=0.9,*/*;q=0.8", the default is preferred for them
+	if def := renderers[0]; acceptsHTML(ranges) && (def.Accepts == nil || def.Accepts(v)) {
+		for _, ar := range ranges {
+			if matchMediaType(ar.mediaRange, def.ContentType) {
+				return def, true
+			}
+		}
+	}
+
+	// the highest quality, the renderer registered first on ties, eg: json
+	for i := 0; i < len(ranges); {
+		j := i
+		for j < len(ranges) && ranges[j].q == ranges[i].q {
+			j++
+		}
+		for _, r := range renderers {
+			if r.Accepts != nil && !r.Accepts(v) {
+				continue
+			}
+			for _, ar := range ranges[i:j] {
+				if matchMediaType(ar.mediaRange, r.ContentType) {
+					return r, true
+				}
+			}
+		}
+		i = j
+	}
+	return Renderer{}, false
+}
+
+// acceptsHTML whether the request is from a browser, none of the renderers is html
+func acceptsHTML(ranges []acceptRange) bool {
+	for _, ar := range ranges {
+		if ar.mediaRange == "text/html" {
+			return true
+		}
+	}
+	return false
+}
+
+// respond the value in the negotiated content type,
+// or 406 NOT_ACCEPTABLE if nothing matches
+func respond(ctx *gin.Context, status int, v interface{}) {
+	ctx.Header("Vary", vary)
+	v, err := selected(ctx, v)
+	if err != nil {
+		Failure(ctx, errors.NewInternalServerError(err))
+		return
+	}
+
+	r, ok := negotiate(ctx, v)
+	if !ok {
+		Failure(ctx, errors.NewNotAcceptable(ctx.GetHeader("Accept"), strings.Join(acceptable(), ", ")))
+		return
+	}
+	if status == http.StatusOK && notModified(ctx, r.ContentType, v) {
+		return
+	}
+	r.Render(ctx, status, r.ContentType, v)
+}
+
+// selected trims the data of the response to the fields selected
+// by ?fields= and ?include=, see fields.Parse
+func selected(ctx *gin.Context, v interface{}) (interface{}, error) {
+	res, ok := v.(Response)
+	if !ok {
+		return v, nil
+	}
+	if sel := fields.Get(ctx); sel != nil && res.Data != nil {
+		data, err := sel.Trim(res.Data)
+		if err != nil {
+			return nil, err
+		}
+		res.Data = data
+	}
+	return res, nil
+}
+
+// acceptRange the media range of Accept with its quality
+type acceptRange struct {
+	mediaRange string
+	q          float64
+}
+
+// parseAccept returns the media ranges of Accept sorted by quality,
+// eg: "text/csv, application/json;q=0.9" => [text/csv application/json]
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		mediaRange := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mediaRange == "" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				if v, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, acceptRange{mediaRange, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	return ranges
+}
+
+// matchMediaType whether the media range matches the content type,
+// eg: "*/*" and "application/*" match "application/json"
+func matchMediaType(mediaRange, contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	if mediaRange == "*/*" || mediaRange == contentType {
+		return true
+	}
+	if strings.HasSuffix(mediaRange, "/*") {
+		return strings.HasPrefix(contentType, strings.TrimSuffix(mediaRange, "*"))
+	}
+	return false
+}
+
+// acceptable the media types of the registered renderers
+func acceptable() []string {
+	renderersLock.RLock()
+	defer renderersLock.RUnlock()
+
+	types := make([]string, 0, len(renderers))
+	for _, r := range renderers {
+		types = append(types, r.ContentType)
+	}
+	return types
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseAccept(t *testing.T) {
+	got := parseAccept("text/csv;q=0.5, application/xml, */*;q=0.1, text/html;q=0")
+	want := []acceptRange{{"application/xml", 1}, {"text/csv", 0.5}, {"*/*", 0.1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseAccept = %v, want %v", got, want)
+	}
+}
+
+func TestMatchMediaType(t *testing.T) {
+	cases := []struct {
+		mediaRange  string
+		contentType string
+		match       bool
+	}{
+		{"*/*", "application/json", true},
+		{"application/*", "application/json", true},
+		{"application/json", "application/json", true},
+		{"text/*", "application/json", false},
+		{"text/csv", "text/xml", false},
+	}
+	for _, c := range cases {
+		if got := matchMediaType(c.mediaRange, c.contentType); got != c.match {
+			t.Errorf("matchMediaType(%q, %q) = %v", c.mediaRange, c.contentType, got)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	list := Response{Data: []map[string]string{{"id": "1"}}}
+	cases := []struct {
+		accept      string
+		v           interface{}
+		contentType string
+	}{
+		{"", list, "application/json"},
+		{"application/xml, application/json", list, "application/json"},
+		{"application/xml, text/csv;q=0.9", list, "application/xml"},
+		{"text/csv, application/xml;q=0.5", list, "text/csv"},
+		{"text/csv, application/xml;q=0.5", Response{Data: "a"}, "application/xml"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", list, "application/json"},
+		{"text/html,application/xml", list, "application/xml"},
+	}
+	for _, c := range cases {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		ctx.Request.Header.Set("Accept", c.accept)
+		r, ok := negotiate(ctx, c.v)
+		if !ok || r.ContentType != c.contentType {
+			t.Errorf("Accept %q: got %s, %v, want %s", c.accept, r.ContentType, ok, c.contentType)
+		}
+	}
+}
+
+func TestCSVText(t *testing.T) {
+	cases := map[string]string{
+		"admin":             "admin",
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"+1":                "'+1",
+		"-1":                "'-1",
+		"@SUM(A1)":          "'@SUM(A1)",
+		"":                  "",
+	}
+	for in, want := range cases {
+		if got := csvText(in); got != want {
+			t.Errorf("csvText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestIsList(t *testing.T) {
+	type row struct {
+		ID int `json:"id"`
+	}
+	cases := []struct {
+		v    interface{}
+		want bool
+	}{
+		{Response{Data: []row{{1}}}, true},
+		{Response{Data: []*row{{1}}}, true},
+		{Response{Data: []interface{}{}}, true},
+		{Response{Data: row{1}}, false},
+		{Response{Data: []string{"a"}}, false},
+		{Response{Data: map[string]string{}}, false},
+	}
+	for _, c := range cases {
+		if got := isList(c.v); got != c.want {
+			t.Errorf("isList(%#v) = %v, want %v", c.v, got, c.want)
+		}
+	}
+}
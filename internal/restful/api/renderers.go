@@ -0,0 +1,295 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+	"gopkg.in/yaml.v3"
+)
+
+func renderJSON(ctx *gin.Context, status int, contentType string, v interface{}) {
+	ctx.JSON(status, v)
+}
+
+func renderMsgPack(ctx *gin.Context, status int, contentType string, v interface{}) {
+	ctx.Header("Content-Type", contentType)
+	ctx.Render(status, render.MsgPack{Data: v})
+}
+
+// renderYAML renders the value with the field names of json tags
+func renderYAML(ctx *gin.Context, status int, contentType string, v interface{}) {
+	tree, err := jsonTree(v)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	data, err := yaml.Marshal(plainNumbers(tree))
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.Data(status, contentType+"; charset=utf-8", data)
+}
+
+// renderXML renders the value with the field names of json tags,
+// the root element is <response> and the list items are <item>, eg:
+//
+//	<response><code>0</code><data><item><id>1</id></item></data></response>
+func renderXML(ctx *gin.Context, status int, contentType string, v interface{}) {
+	tree, err := jsonTree(v)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	buf := bytes.NewBufferString(xml.Header)
+	enc := xml.NewEncoder(buf)
+	if err = encodeXML(enc, "response", tree); err == nil {
+		err = enc.Flush()
+	}
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.Data(status, contentType+"; charset=utf-8", buf.Bytes())
+}
+
+// renderCSV renders the list data, one row per item, the header
+// is the field names of json tags, the nested values are in json
+func renderCSV(ctx *gin.Context, status int, contentType string, v interface{}) {
+	data := listData(v)
+	columns := csvColumns(data)
+
+	tree, err := jsonTree(data.Interface())
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	_ = w.Write(columns)
+	items, _ := tree.([]interface{})
+	for _, item := range items {
+		fields, _ := item.(map[string]interface{})
+		row := make([]string, 0, len(columns))
+		for _, col := range columns {
+			row = append(row, csvValue(fields[col]))
+		}
+		_ = w.Write(row)
+	}
+	w.Flush()
+	if err = w.Error(); err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.Data(status, contentType+"; charset=utf-8", buf.Bytes())
+}
+
+// renderError responds 500 in json if the value can't be rendered
+func renderError(ctx *gin.Context, err error) {
+	failure(ctx, errors.NewInternalServerError(err), false)
+}
+
+// isList whether the data of the response is a list of objects
+func isList(v interface{}) bool {
+	data := listData(v)
+	if !data.IsValid() {
+		return false
+	}
+	elem := data.Type().Elem()
+	for elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Interface {
+		if elem.Kind() == reflect.Interface {
+			return true
+		}
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct || elem.Kind() == reflect.Map
+}
+
+// listData returns the slice of Response.Data, or the slice itself
+func listData(v interface{}) reflect.Value {
+	if r, ok := v.(Response); ok {
+		v = r.Data
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return reflect.Value{}
+	}
+	return rv
+}
+
+// csvColumns returns the json names of the struct fields in order,
+// or the sorted keys of all the maps
+func csvColumns(data reflect.Value) []string {
+	elem := data.Type().Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() == reflect.Struct {
+		return structColumns(elem)
+	}
+
+	tree, _ := jsonTree(data.Interface())
+	items, _ := tree.([]interface{})
+	seen := map[string]bool{}
+	var columns []string
+	for _, item := range items {
+		fields, _ := item.(map[string]interface{})
+		for k := range fields {
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+func structColumns(t reflect.Type) []string {
+	var columns []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			columns = append(columns, structColumns(ft)...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		columns = append(columns, name)
+	}
+	return columns
+}
+
+func csvValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return csvText(t)
+	case json.Number:
+		return t.String()
+	case bool:
+		return fmt.Sprint(t)
+	default:
+		data, _ := json.Marshal(t)
+		return string(data)
+	}
+}
+
+// csvText prefixes the text with ' if it would be a formula in the spreadsheets,
+// eg: =HYPERLINK(...), +1, -1, @SUM(...)
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// jsonTree converts the value to the generic tree of json,
+// the numbers are kept as json.Number
+func jsonTree(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var tree interface{}
+	if err = dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// plainNumbers replaces json.Number of the tree with int64 or float64
+func plainNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, item := range t {
+			t[k] = plainNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range t {
+			t[i] = plainNumbers(item)
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	}
+	return v
+}
+
+func encodeXML(enc *xml.Encoder, name string, v interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: xmlName(name)}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := encodeXML(enc, k, t[k]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range t {
+			if err := encodeXML(enc, "item", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(t))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// xmlName replaces the invalid characters of the element name with '_'
+func xmlName(name string) string {
+	b := []rune(name)
+	for i, r := range b {
+		valid := r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' ||
+			i > 0 && (r == '-' || r == '.' || r >= '0' && r <= '9')
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
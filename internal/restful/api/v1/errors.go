@@ -0,0 +1,22 @@
+package v2
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/zliang90/kingRest/internal/restful/api"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+)
+
+// Errors returns the catalog of the api errors, cached by ETag of the
+// representation, the developer messages are hidden in prod
+func Errors(ctx *gin.Context) {
+	catalog := errors.Catalog()
+	etag, err := api.ETagOf(ctx, catalog)
+	if err != nil {
+		api.Failure(ctx, errors.NewInternalServerError(err))
+		return
+	}
+	if api.NotModified(ctx, etag) {
+		return
+	}
+	api.SuccessWithTotal(ctx, catalog, len(catalog))
+}
@@ -0,0 +1,72 @@
+package v2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+)
+
+func getErrors(t *testing.T, header http.Header) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/errors", Errors)
+
+	req := httptest.NewRequest(http.MethodGet, "/errors", nil)
+	for k := range header {
+		req.Header.Set(k, header.Get(k))
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestErrorsETag(t *testing.T) {
+	if err := errors.LoadMessages("../../../../config/errors.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	etags := map[string]bool{}
+	for _, h := range []http.Header{
+		{"Accept": {"application/json"}, "Accept-Language": {"zh-CN"}},
+		{"Accept": {"application/xml"}, "Accept-Language": {"zh-CN"}},
+		{"Accept": {"text/csv"}, "Accept-Language": {"zh-CN"}},
+		{"Accept": {"application/json"}, "Accept-Language": {"en-US"}},
+	} {
+		w := getErrors(t, h)
+		etag := w.Header().Get("ETag")
+		if w.Code != http.StatusOK || etag == "" {
+			t.Fatalf("%v: got %d, etag %q", h, w.Code, etag)
+		}
+		if vary := w.Header().Get("Vary"); !strings.Contains(vary, "Accept") || !strings.Contains(vary, "Accept-Language") {
+			t.Errorf("%v: Vary %q", h, vary)
+		}
+		if etags[etag] {
+			t.Errorf("%v: the same etag %s of the other representation", h, etag)
+		}
+		etags[etag] = true
+
+		h.Set("If-None-Match", etag)
+		if w = getErrors(t, h); w.Code != http.StatusNotModified {
+			t.Errorf("%v: got %d, want 304", h, w.Code)
+		}
+	}
+}
+
+func TestErrorsProd(t *testing.T) {
+	if err := errors.LoadMessages("../../../../config/errors.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	defer errors.SetEnv("")
+
+	for env, hidden := range map[string]bool{"dev": false, "prod": true} {
+		errors.SetEnv(env)
+		w := getErrors(t, http.Header{"Accept": {"application/json"}})
+		if got := !strings.Contains(w.Body.String(), "developer_message"); got != hidden {
+			t.Errorf("%s: developer messages hidden %v, want %v", env, got, hidden)
+		}
+	}
+}
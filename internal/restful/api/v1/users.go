@@ -0,0 +1,142 @@
+package v2
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/zliang90/kingRest/internal/app/db"
+	"github.com/zliang90/kingRest/internal/app/service"
+	"github.com/zliang90/kingRest/internal/restful/api"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+	"github.com/zliang90/kingRest/internal/restful/fields"
+	"github.com/zliang90/kingRest/internal/restful/filter"
+	"github.com/zliang90/kingRest/internal/restful/pagination"
+	"github.com/zliang90/kingRest/internal/restful/validator"
+)
+
+// ListUsers returns the users of the page, eg:
+//
+//	/api/v1/users?page=2&size=20&filter=name startswith "admin"&sort=-created_at&fields=id,name
+func ListUsers(ctx *gin.Context) {
+	p, err := pagination.Parse(ctx)
+	if err != nil {
+		api.Failure(ctx, err)
+		return
+	}
+	q, err := filter.Parse(ctx, service.UserFilter)
+	if err != nil {
+		api.Failure(ctx, err)
+		return
+	}
+	sel, err := fields.Parse(ctx, service.UserFields)
+	if err != nil {
+		api.Failure(ctx, err)
+		return
+	}
+	users, page, err := service.NewUser(ctx).ListUsers(p, q, sel)
+	if err != nil {
+		api.Failure(ctx, errors.NewInternalServerError(err))
+		return
+	}
+	api.SuccessWithPage(ctx, users, page)
+}
+
+// the key of the user read for the precondition in gin context
+const userKey = "User"
+
+// GetUser returns the user of the id, with ETag and Last-Modified for the
+// conditional requests, eg: /api/v1/users/:id?fields=id,name
+func GetUser(ctx *gin.Context) {
+	if _, err := fields.Parse(ctx, service.UserFields); err != nil {
+		api.Failure(ctx, err)
+		return
+	}
+	user, err := getUser(ctx)
+	if err != nil {
+		api.Failure(ctx, err)
+		return
+	}
+	if api.NotModifiedSince(ctx, api.VersionETag(ctx, user, user.UpdatedAt.Time), user.UpdatedAt.Time) {
+		return
+	}
+	api.Success(ctx, user)
+}
+
+// UserVersion returns the ETag of the user of the request as responded by GetUser,
+// and its updated time, see api.HandlerPrecondition
+func UserVersion(ctx *gin.Context) (string, time.Time, error) {
+	if _, err := fields.Parse(ctx, service.UserFields); err != nil {
+		return "", time.Time{}, err
+	}
+	user, err := getUser(ctx)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	// the version checked is the one to be updated
+	ctx.Set(userKey, user)
+	return api.VersionETag(ctx, user, user.UpdatedAt.Time), user.UpdatedAt.Time, nil
+}
+
+type updateUser struct {
+	Name string `json:"name" validate:"required,max=128"`
+}
+
+// UpdateUser updates the name of the user, the If-Match is enforced by
+// api.HandlerPrecondition with UserVersion, eg:
+//
+//	PATCH /api/v1/users/:id
+//	If-Match: "3f2a..."
+//
+//	{"name": "admin"}
+func UpdateUser(ctx *gin.Context) {
+	var req updateUser
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		api.Failure(ctx, validator.BadRequest(err))
+		return
+	}
+	if _, err := fields.Parse(ctx, service.UserFields); err != nil {
+		api.Failure(ctx, err)
+		return
+	}
+
+	// the user checked by the precondition, or read now without If-Match
+	user, _ := ctx.Value(userKey).(*db.User)
+	if user == nil {
+		var err error
+		if user, err = getUser(ctx); err != nil {
+			api.Failure(ctx, err)
+			return
+		}
+	}
+
+	s := service.NewUser(ctx)
+	if err := s.UpdateUser(user, req.Name); err != nil {
+		if err == service.ErrUserModified {
+			api.Failure(ctx, errors.NewPreconditionFailed("updated_at: "+user.UpdatedAt.Format(time.RFC3339Nano)))
+			return
+		}
+		api.Failure(ctx, errors.NewInternalServerError(err))
+		return
+	}
+	user, err := getUser(ctx)
+	if err != nil {
+		api.Failure(ctx, err)
+		return
+	}
+	api.Success(ctx, user)
+}
+
+// getUser returns the user of the id in path, NOT_FOUND if it doesn't exist
+func getUser(ctx *gin.Context) (*db.User, error) {
+	id := ctx.Param("id")
+	user, err := service.NewUser(ctx).GetUser(id)
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, errors.NewNotFound(fmt.Sprintf("user '%s'", id))
+	}
+	if err != nil {
+		return nil, errors.NewInternalServerError(err)
+	}
+	return user, nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/zliang90/kingRest/internal/restful/validator"
 	"github.com/zliang90/kingRest/pkg/log"
 	"github.com/zliang90/kingRest/pkg/util/table"
+	"net"
 	"net/http"
 	"time"
 )
@@ -23,6 +24,9 @@ type Server struct {
 	// web server config
 	cf conf.WebServer
 
+	// pprof etc. are served by the admin server if it's configured
+	admin bool
+
 	// serve handler
 	r http.Handler
 
@@ -32,87 +36,127 @@ type Server struct {
 
 func New(cf *conf.Config) *Server {
 	return &Server{
-		env: cf.Env,
-		cf:  cf.WebServer,
+		env:   cf.Env,
+		cf:    cf.WebServer,
+		admin: cf.AdminServer != nil,
 	}
 }
 
-func (s *Server) Run(ctx context.Context) {
-	defer func() {
-		if e := recover(); e != nil {
-			log.Errorf("%v", e)
-		}
-	}()
+// Start listening on the address and serving in background,
+// it returns once the listener is ready
+func (s *Server) Start() error {
 	// init engine, and run it
 	log.Info("start restful api")
-	go s.serve()
+	s.Handler()
+	if s.env != "prod" {
+		s.PrintRouters()
+	}
 
-	select {
-	case <-ctx.Done():
-		log.Info("stopping restful api")
-		if err := s.srv.Shutdown(ctx); err != nil {
-			log.Fatal(err)
+	l, err := listen(listenerWeb, s.cf.Addr, s.cf.SocketMode)
+	if err != nil {
+		return err
+	}
+	log.Infof("serving restful api on: %s", l.Addr())
+
+	// web http server
+	s.srv = &http.Server{
+		Handler:           s.r,
+		MaxHeaderBytes:    s.cf.MaxHeaderBytes,
+		ReadTimeout:       time.Duration(s.cf.ReadTimeout) * time.Second,
+		ReadHeaderTimeout: time.Duration(s.cf.ReadHeaderTimeout) * time.Second,
+		WriteTimeout:      time.Duration(s.cf.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(s.cf.IdleTimeout) * time.Second,
+	}
+	if s.cf.TLS != nil {
+		r, err := newTLSReloader(*s.cf.TLS)
+		if err != nil {
+			l.Close()
+			return err
 		}
+		s.srv.TLSConfig = r.TLSConfig()
+		log.Infof("serving restful api with tls, client auth: '%s'", s.cf.TLS.ClientAuth)
 	}
-	return
+	go s.serve(l)
+	return nil
 }
 
-func (s *Server) serve() {
-	log.Infof("register routers")
-	s.r = router.InitRouter(s.env)
+// Shutdown stop accepting and wait for the in-flight requests until the context
+// is done, the remaining connections are closed if the drain is not completed
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.srv == nil {
+		return nil
+	}
+	log.Info("stopping restful api")
+	if err := s.srv.Shutdown(ctx); err != nil {
+		s.srv.Close()
+		return fmt.Errorf("drain restful api, %v", err)
+	}
+	return nil
+}
+
+// Handler returns the http handler with routers and middleware registered
+func (s *Server) Handler() http.Handler {
+	if s.r == nil {
+		s.r = s.initHandler()
+	}
+	return s.r
+}
 
+func (s *Server) initHandler() http.Handler {
 	// upgrade to v10
 	log.Infof("upgrade gin validator to v10")
 	validator.ToV10()
 
-	// no route
-	s.r.(*gin.Engine).NoRoute(func(c *gin.Context) {
-		api.Failure(c, errors.NotFound(
-			fmt.Sprintf("%s '%s'", c.Request.Method, c.Request.URL)))
-	})
 	// middleware
 	middleware := []gin.HandlerFunc{
 		handlerRequestId(),
+		handlerMetrics(),
 		handlerRecovery(),
+		handlerClientCert(),
 	}
 	if s.env != "prod" {
 		middleware = append(middleware, handlerLogger())
-		log.Debugf("register pprof routers")
-		pprof.Register(s.r.(*gin.Engine))
-
-		s.printRouters()
 	}
-	s.r.(*gin.Engine).Use(middleware...)
 
-	log.Infof("serving restful api on: %s", s.cf.Addr)
+	log.Infof("register routers")
+	engine := router.InitRouter(s.env, middleware...).(*gin.Engine)
 
-	// web http server
-	srv := &http.Server{
-		Addr:              s.cf.Addr,
-		Handler:           s.r,
-		MaxHeaderBytes:    s.cf.MaxHeaderBytes,
-		ReadTimeout:       time.Duration(s.cf.ReadTimeout) * time.Second,
-		ReadHeaderTimeout: time.Duration(s.cf.ReadHeaderTimeout) * time.Second,
-		WriteTimeout:      time.Duration(s.cf.WriteTimeout) * time.Second,
-		IdleTimeout:       time.Duration(s.cf.IdleTimeout) * time.Second,
+	// no route
+	engine.NoRoute(func(c *gin.Context) {
+		api.Failure(c, errors.NewNotFound(
+			fmt.Sprintf("%s '%s'", c.Request.Method, c.Request.URL)))
+	})
+	if s.env != "prod" && !s.admin {
+		log.Debugf("register pprof routers")
+		pprof.Register(engine)
+	}
+	return engine
+}
+
+func (s *Server) serve(l net.Listener) {
+	var err error
+	if s.srv.TLSConfig != nil {
+		// certificates are provided by the tls config
+		err = s.srv.ServeTLS(l, "", "")
+	} else {
+		err = s.srv.Serve(l)
 	}
-	s.srv = srv
-	if err := srv.ListenAndServe(); err != nil {
+	if err != nil {
 		if err != http.ErrServerClosed {
 			log.Fatal(err)
 		}
 		log.Info(err)
 	}
-	//log.Fatal(s.r.(*gin.Engine).Run(s.l))
 }
 
-func (s *Server) printRouters() {
+// PrintRouters print the route table
+func (s *Server) PrintRouters() {
 	t := &table.Table{}
 
 	tHeader := []string{"METHOD", "PATH"}
 	tData := make([][]string, 0)
 
-	routers := s.r.(*gin.Engine).Routes()
+	routers := s.Handler().(*gin.Engine).Routes()
 	for _, r := range routers {
 		d := []string{r.Method, r.Path}
 		tData = append(tData, d)
@@ -1,13 +1,56 @@
 package errors
 
+import "net/http"
+
 type APIError struct {
+	// error template key, eg: NOT_FOUND
+	Key string `json:"-"`
+
+	// http status of the response
+	Status int `json:"-"`
+
 	RequestId        string      `json:"request_id,omitempty"`
 	Code             int64       `json:"code"`
 	Message          string      `json:"message,omitempty"`
 	DeveloperMessage string      `json:"developer_message,omitempty"`
 	Details          interface{} `json:"details,omitempty"`
+
+	// the params of the template, to localize the messages
+	params Params
+
+	// the underlying error and the stack where it's created
+	cause error
+	stack []uintptr
 }
 
 func (e APIError) Error() string {
 	return e.Message
 }
+
+// HTTPStatus returns the http status of the response,
+// it's always 200 if the legacy status is enabled
+func (e APIError) HTTPStatus() int {
+	if GetSettings().LegacyStatus {
+		return http.StatusOK
+	}
+	if e.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.Status
+}
+
+// Localizer the details can be localized by Accept-Language
+type Localizer interface {
+	Localize(acceptLanguage string)
+}
+
+// Localize render the messages and details in the locale best matching the Accept-Language
+func (e *APIError) Localize(acceptLanguage string) {
+	if acceptLanguage == "" {
+		return
+	}
+	e.render(parseAcceptLanguage(acceptLanguage))
+	if l, ok := e.Details.(Localizer); ok {
+		l.Localize(acceptLanguage)
+	}
+}
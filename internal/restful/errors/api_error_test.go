@@ -0,0 +1,83 @@
+package errors
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetHTTPStatus(t *testing.T) {
+	cases := []struct {
+		tpl  errorTemplate
+		want int
+	}{
+		{errorTemplate{Code: 1000404}, http.StatusNotFound},
+		{errorTemplate{Code: 1000400}, http.StatusBadRequest},
+		{errorTemplate{Code: 1000401}, http.StatusUnauthorized},
+		{errorTemplate{Code: 1000412}, http.StatusPreconditionFailed},
+		{errorTemplate{Code: 1000503}, http.StatusServiceUnavailable},
+		{errorTemplate{Code: 1000599}, 599},
+		// not a 4xx/5xx suffix
+		{errorTemplate{Code: 1000200}, http.StatusInternalServerError},
+		{errorTemplate{Code: 1000302}, http.StatusInternalServerError},
+		{errorTemplate{Code: 1000600}, http.StatusInternalServerError},
+		{errorTemplate{Code: 1000001}, http.StatusInternalServerError},
+		{errorTemplate{}, http.StatusInternalServerError},
+		// http_status is preferred
+		{errorTemplate{Code: 1000404, HTTPStatus: http.StatusGone}, http.StatusGone},
+		{errorTemplate{Code: 2000001, HTTPStatus: http.StatusConflict}, http.StatusConflict},
+	}
+	for _, c := range cases {
+		if got := c.tpl.getHTTPStatus(); got != c.want {
+			t.Errorf("code %d, http_status %d: got %d, want %d", c.tpl.Code, c.tpl.HTTPStatus, got, c.want)
+		}
+	}
+}
+
+func TestHTTPStatusLegacy(t *testing.T) {
+	defer SetLegacyStatus(false)
+
+	cases := []struct {
+		status int
+		legacy bool
+		want   int
+	}{
+		{http.StatusNotFound, false, http.StatusNotFound},
+		{0, false, http.StatusInternalServerError},
+		{http.StatusNotFound, true, http.StatusOK},
+		{http.StatusInternalServerError, true, http.StatusOK},
+		{0, true, http.StatusOK},
+	}
+	for _, c := range cases {
+		SetLegacyStatus(c.legacy)
+		e := APIError{Status: c.status}
+		if got := e.HTTPStatus(); got != c.want {
+			t.Errorf("status %d, legacy %v: got %d, want %d", c.status, c.legacy, got, c.want)
+		}
+		if got := e.Problem("/users").Status; got != c.want {
+			t.Errorf("status %d, legacy %v: got problem status %d, want %d", c.status, c.legacy, got, c.want)
+		}
+	}
+}
+
+func TestFallbackStatus(t *testing.T) {
+	prev := load().templates
+	defer Apply(GetSettings(), &Messages{templates: prev})
+	Apply(GetSettings(), &Messages{templates: map[string]errorTemplate{}})
+
+	cases := []struct {
+		key    string
+		status int
+	}{
+		{KeyNotAcceptable, http.StatusNotAcceptable},
+		{KeyPreconditionRequired, http.StatusPreconditionRequired},
+		{KeyNotFound, http.StatusNotFound},
+		{KeyBadRequest, http.StatusBadRequest},
+		{"UNDEFINED", http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		e := NewAPIError(c.key, nil)
+		if e.HTTPStatus() != c.status || e.Code != 1000000+int64(c.status) || e.Message != c.key {
+			t.Errorf("%s: got %d %d %s, want %d", c.key, e.HTTPStatus(), e.Code, e.Message, c.status)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package errors
+
+import "sort"
+
+// CatalogEntry the api error template exposed to the clients
+type CatalogEntry struct {
+	Key        string `json:"key"`
+	Code       int64  `json:"code"`
+	HTTPStatus int    `json:"http_status"`
+
+	// messages keyed by locale
+	Message          map[string]string `json:"message"`
+	DeveloperMessage map[string]string `json:"developer_message,omitempty"`
+
+	// the placeholder names of the messages, eg: resource of "{resource} was not found."
+	Placeholders []string `json:"placeholders,omitempty"`
+}
+
+// Catalog returns the loaded api error templates sorted by key,
+// the developer messages are hidden in prod
+func Catalog() []CatalogEntry {
+	st := load()
+	catalog := make([]CatalogEntry, 0, len(st.templates))
+	for key, t := range st.templates {
+		entry := CatalogEntry{
+			Key:        key,
+			Code:       t.getErrorCode(),
+			HTTPStatus: t.getHTTPStatus(),
+			Message:    t.Message.byLocale(st.DefaultLocale),
+		}
+		if st.Env != "prod" {
+			entry.DeveloperMessage = t.DeveloperMessage.byLocale(st.DefaultLocale)
+		}
+		for _, text := range []localizedText{t.Message, t.DeveloperMessage} {
+			for _, v := range text {
+				for _, p := range placeholders(v) {
+					if !contains(entry.Placeholders, p) {
+						entry.Placeholders = append(entry.Placeholders, p)
+					}
+				}
+			}
+		}
+		sort.Strings(entry.Placeholders)
+		catalog = append(catalog, entry)
+	}
+	sort.Slice(catalog, func(i, j int) bool {
+		return catalog[i].Key < catalog[j].Key
+	})
+	return catalog
+}
@@ -0,0 +1,141 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// the max depth of the captured stack
+const maxStackDepth = 32
+
+// the depth of the stack captured in prod, it covers the frames of this
+// package, up to 3, and a few frames of the caller
+const shallowStackDepth = 8
+
+// the function prefix of this package, whose frames are skipped in the stack
+var pkgPrefix = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	name := runtime.FuncForPC(pc).Name()
+	i := strings.LastIndex(name, "/")
+	return name[:i+strings.Index(name[i:], ".")+1]
+}()
+
+// Debug the debug info of the api error, it's set as the details
+// if there are no other details and Env != prod
+type Debug struct {
+	Causes []string `json:"causes,omitempty"`
+	Stack  []string `json:"stack,omitempty"`
+}
+
+// Unwrap returns the underlying cause, see errors.Unwrap
+func (e *APIError) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether the target is an api error of the same key, eg:
+//
+//	errors.Is(err, &APIError{Key: KeyNotFound})
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	return ok && t.Key != "" && t.Key == e.Key
+}
+
+// IsKey reports whether any api error in the chain of err has the key
+func IsKey(err error, key string) bool {
+	return stderrors.Is(err, &APIError{Key: key})
+}
+
+// WithCause set the underlying cause of the api error
+func (e *APIError) WithCause(err error) *APIError {
+	e.cause = err
+	return e
+}
+
+// Recovered converts the value recovered from panic to the api error,
+// with the stack of the panic, it must be called in the deferred function
+func Recovered(v interface{}) *APIError {
+	e, ok := v.(*APIError)
+	if !ok {
+		e = NewUnknownError(v)
+	}
+
+	// the frames from the panic, they're captured even in prod
+	stack := stackOf(1, maxStackDepth)
+	for i, pc := range stack {
+		if fn := runtime.FuncForPC(pc - 1); fn != nil && fn.Name() == "runtime.gopanic" {
+			stack = stack[i+1:]
+			break
+		}
+	}
+	e.stack = stack
+	return e
+}
+
+// Causes returns the messages of the cause chain
+func (e *APIError) Causes() []string {
+	var causes []string
+	for err := e.cause; err != nil; err = stderrors.Unwrap(err) {
+		causes = append(causes, err.Error())
+	}
+	return causes
+}
+
+// Stack returns the frames where the api error is created or the panic is recovered,
+// eg: "main.handler (/src/main.go:21)"
+func (e *APIError) Stack() []string {
+	var stack []string
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		f, more := frames.Next()
+		if f.Function != "" && !strings.HasPrefix(f.Function, pkgPrefix) {
+			stack = append(stack, fmt.Sprintf("%s (%s:%d)", f.Function, f.File, f.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// Debug returns the cause chain and the stack
+func (e *APIError) Debug() *Debug {
+	return &Debug{Causes: e.Causes(), Stack: e.Stack()}
+}
+
+// callers returns the stack of the caller, only a shallow stack is captured
+// in prod to keep the errors cheap, see Recovered for the stack of panics
+func callers() []uintptr {
+	if GetSettings().Env == "prod" {
+		return stackOf(2, shallowStackDepth)
+	}
+	return stackOf(2, maxStackDepth)
+}
+
+// stackOf returns the stack skipping the frames, 1 is the caller of stackOf
+func stackOf(skip, depth int) []uintptr {
+	pcs := make([]uintptr, depth)
+	n := runtime.Callers(skip+1, pcs)
+	return pcs[:n]
+}
+
+// causeOf returns the error of the "error" param as the cause, or the error
+// of the first param in the sorted keys
+func causeOf(params Params) error {
+	if err, ok := params["error"].(error); ok {
+		return err
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err, ok := params[k].(error); ok {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package errors_test
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/zliang90/kingRest/internal/restful/errors"
+)
+
+func TestCause(t *testing.T) {
+	e := errors.NewAPIError(errors.KeyNotFound, errors.Params{"resource": "user"}).WithCause(io.EOF)
+	err := fmt.Errorf("get user: %w", e)
+
+	if !errors.IsKey(err, errors.KeyNotFound) || errors.IsKey(err, errors.KeyBadRequest) {
+		t.Error("errors.IsKey mismatched the key of the chain")
+	}
+	if !stderrors.Is(err, io.EOF) {
+		t.Error("cause is not unwrapped")
+	}
+	var ae *errors.APIError
+	if !stderrors.As(err, &ae) || ae.Key != errors.KeyNotFound {
+		t.Error("api error is not found in the chain")
+	}
+
+	if causes := e.Causes(); len(causes) != 1 || causes[0] != io.EOF.Error() {
+		t.Errorf("causes = %v", causes)
+	}
+	stack := e.Stack()
+	if len(stack) == 0 || !strings.Contains(stack[0], "TestCause") {
+		t.Errorf("stack should start at the caller, got %v", stack)
+	}
+}
+
+func TestRecovered(t *testing.T) {
+	var e *errors.APIError
+	func() {
+		defer func() {
+			e = errors.Recovered(recover())
+		}()
+		panic(io.ErrUnexpectedEOF)
+	}()
+
+	if e.Key != errors.KeyUnknownError || !stderrors.Is(e, io.ErrUnexpectedEOF) {
+		t.Errorf("recovered %s, cause %v", e.Key, e.Unwrap())
+	}
+	if stack := e.Stack(); len(stack) == 0 || !strings.Contains(stack[0], "TestRecovered.func") {
+		t.Errorf("stack should start at the panic, got %v", stack)
+	}
+}
+
+func TestCauseOf(t *testing.T) {
+	cases := []struct {
+		params errors.Params
+		want   error
+	}{
+		{errors.Params{"resource": "user"}, nil},
+		{errors.Params{"error": io.EOF, "a": io.ErrClosedPipe, "z": io.ErrShortWrite}, io.EOF},
+		{errors.Params{"z": io.ErrShortWrite, "b": io.ErrClosedPipe, "a": "user"}, io.ErrClosedPipe},
+		{errors.Params{"error": "not an error", "c": io.ErrShortWrite, "b": io.ErrClosedPipe}, io.ErrClosedPipe},
+	}
+	for _, c := range cases {
+		// the map order is random
+		for i := 0; i < 10; i++ {
+			if got := errors.NewAPIError(errors.KeyUnknownError, c.params).Unwrap(); got != c.want {
+				t.Fatalf("%v: got cause %v, want %v", c.params, got, c.want)
+			}
+		}
+	}
+}
+
+func TestStackInProd(t *testing.T) {
+	defer errors.SetEnv("")
+	errors.SetEnv("prod")
+
+	// a shallow stack of the caller
+	stack := errors.NewNotFound("user").Stack()
+	if len(stack) == 0 || len(stack) > 8 || !strings.Contains(stack[0], "TestStackInProd") {
+		t.Errorf("unexpected stack in prod: %v", stack)
+	}
+
+	// the stack of the panic is still captured
+	var e *errors.APIError
+	func() {
+		defer func() {
+			e = errors.Recovered(recover())
+		}()
+		panic(io.ErrUnexpectedEOF)
+	}()
+	if stack := e.Stack(); len(stack) == 0 || !strings.Contains(stack[0], "TestStackInProd.func") {
+		t.Errorf("stack should start at the panic, got %v", stack)
+	}
+}
@@ -0,0 +1,55 @@
+// Code generated by go run ./gen; DO NOT EDIT.
+
+package errors
+
+// keys of the api error templates
+const (
+	KeyBadRequest           = "BAD_REQUEST"
+	KeyInternalServerError  = "INTERNAL_SERVER_ERROR"
+	KeyNotAcceptable        = "NOT_ACCEPTABLE"
+	KeyNotFound             = "NOT_FOUND"
+	KeyPreconditionFailed   = "PRECONDITION_FAILED"
+	KeyPreconditionRequired = "PRECONDITION_REQUIRED"
+	KeyUnauthorized         = "UNAUTHORIZED"
+	KeyUnknownError         = "UNKNOWN_ERROR"
+)
+
+// NewBadRequest returns BAD_REQUEST api error, code 1000400
+func NewBadRequest(err error) *APIError {
+	return NewAPIError(KeyBadRequest, Params{"error": err})
+}
+
+// NewInternalServerError returns INTERNAL_SERVER_ERROR api error, code 1000500
+func NewInternalServerError(err error) *APIError {
+	return NewAPIError(KeyInternalServerError, Params{"error": err})
+}
+
+// NewNotAcceptable returns NOT_ACCEPTABLE api error, code 1000406
+func NewNotAcceptable(accept string, supported string) *APIError {
+	return NewAPIError(KeyNotAcceptable, Params{"accept": accept, "supported": supported})
+}
+
+// NewNotFound returns NOT_FOUND api error, code 1000404
+func NewNotFound(resource string) *APIError {
+	return NewAPIError(KeyNotFound, Params{"resource": resource})
+}
+
+// NewPreconditionFailed returns PRECONDITION_FAILED api error, code 1000412
+func NewPreconditionFailed(precondition string) *APIError {
+	return NewAPIError(KeyPreconditionFailed, Params{"precondition": precondition})
+}
+
+// NewPreconditionRequired returns PRECONDITION_REQUIRED api error, code 1000428
+func NewPreconditionRequired(precondition string) *APIError {
+	return NewAPIError(KeyPreconditionRequired, Params{"precondition": precondition})
+}
+
+// NewUnauthorized returns UNAUTHORIZED api error, code 1000401
+func NewUnauthorized(err error) *APIError {
+	return NewAPIError(KeyUnauthorized, Params{"error": err})
+}
+
+// NewUnknownError returns UNKNOWN_ERROR api error, code 1000300
+func NewUnknownError(err interface{}) *APIError {
+	return NewAPIError(KeyUnknownError, Params{"error": err})
+}
@@ -0,0 +1,260 @@
+// Command gen generates the typed constructors of the api errors from the
+// api error file, one parameter per placeholder of the messages, the
+// parameter type is `error` for {error}, `string` for the others, or the
+// type hint of `params`, eg:
+//
+//	NOT_FOUND:
+//	  code: 1000404
+//	  developer_message: "{resource} was not found, {count} matched."
+//	  params:
+//	    count: int
+//
+// generates:
+//
+//	const KeyNotFound = "NOT_FOUND"
+//
+//	func NewNotFound(resource string, count int) *APIError {
+//		return NewAPIError(KeyNotFound, Params{"resource": resource, "count": count})
+//	}
+//
+// usage: go generate ./internal/restful/errors
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var placeholderRegexp = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// the parameter names instead of the predeclared identifiers
+var paramNames = map[string]string{
+	"error": "err",
+}
+
+// the supported type hints of the parameters
+var paramTypes = map[string]bool{
+	"string":      true,
+	"error":       true,
+	"int":         true,
+	"int64":       true,
+	"bool":        true,
+	"interface{}": true,
+}
+
+type template struct {
+	key    string
+	code   int64
+	params []string
+	types  map[string]string
+}
+
+func main() {
+	in := flag.String("in", "config/errors.yaml", "api error file, or a directory of per-locale files")
+	out := flag.String("out", "errors_gen.go", "output go file")
+	flag.Parse()
+
+	src, err := generate(*in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err = ioutil.WriteFile(*out, src, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// generate returns the formatted go source of the api error file
+func generate(file string) ([]byte, error) {
+	templates, err := parse(file)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "// Code generated by go run ./gen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(buf, "package errors\n\n")
+
+	fmt.Fprintf(buf, "// keys of the api error templates\n")
+	fmt.Fprintf(buf, "const (\n")
+	for _, t := range templates {
+		fmt.Fprintf(buf, "%s = %q\n", "Key"+camelCase(t.key), t.key)
+	}
+	fmt.Fprintf(buf, ")\n")
+
+	for _, t := range templates {
+		name := camelCase(t.key)
+		args := make([]string, 0, len(t.params))
+		params := make([]string, 0, len(t.params))
+		for _, p := range t.params {
+			args = append(args, paramName(p)+" "+t.paramType(p))
+			params = append(params, fmt.Sprintf("%q: %s", p, paramName(p)))
+		}
+
+		fmt.Fprintf(buf, "\n// New%s returns %s api error, code %d\n", name, t.key, t.code)
+		fmt.Fprintf(buf, "func New%s(%s) *APIError {\n", name, strings.Join(args, ", "))
+		if len(params) == 0 {
+			fmt.Fprintf(buf, "return NewAPIError(Key%s, nil)\n", name)
+		} else {
+			fmt.Fprintf(buf, "return NewAPIError(Key%s, Params{%s})\n", name, strings.Join(params, ", "))
+		}
+		fmt.Fprintf(buf, "}\n")
+	}
+	return format.Source(buf.Bytes())
+}
+
+// parse returns the templates sorted by key, the placeholders are
+// in the order of appearance in message then developer_message
+func parse(file string) ([]template, error) {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{file}
+	if fi.IsDir() {
+		if paths, err = filepath.Glob(filepath.Join(file, "*.yaml")); err != nil {
+			return nil, err
+		}
+		sort.Strings(paths)
+	}
+
+	byKey := map[string]*template{}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var doc map[string]struct {
+			Code             int64             `yaml:"code"`
+			Message          yaml.Node         `yaml:"message"`
+			DeveloperMessage yaml.Node         `yaml:"developer_message"`
+			Params           map[string]string `yaml:"params"`
+		}
+		if err = yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+
+		for key, v := range doc {
+			t, ok := byKey[key]
+			if !ok {
+				t = &template{key: key, types: map[string]string{}}
+				byKey[key] = t
+			}
+			for p, typ := range v.Params {
+				if !paramTypes[typ] {
+					return nil, fmt.Errorf("%s: unsupported type '%s' of %s.params.%s", path, typ, key, p)
+				}
+				t.types[p] = typ
+			}
+			if v.Code != 0 {
+				t.code = v.Code
+			}
+			for _, node := range []yaml.Node{v.Message, v.DeveloperMessage} {
+				for _, text := range texts(node) {
+					for _, m := range placeholderRegexp.FindAllStringSubmatch(text, -1) {
+						if !contains(t.params, m[1]) {
+							t.params = append(t.params, m[1])
+						}
+					}
+				}
+			}
+		}
+	}
+
+	templates := make([]template, 0, len(byKey))
+	for _, t := range byKey {
+		for p := range t.types {
+			if !contains(t.params, p) {
+				return nil, fmt.Errorf("%s.params.%s is not a placeholder of the messages", t.key, p)
+			}
+		}
+		templates = append(templates, *t)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].key < templates[j].key
+	})
+	return templates, nil
+}
+
+// paramType returns the go type of the placeholder
+func (t template) paramType(placeholder string) string {
+	if typ, ok := t.types[placeholder]; ok {
+		return typ
+	}
+	if placeholder == "error" {
+		return "error"
+	}
+	return "string"
+}
+
+// texts returns the plain message, or the messages sorted by locale
+func texts(node yaml.Node) []string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []string{node.Value}
+	case yaml.MappingNode:
+		m := map[string]string{}
+		if err := node.Decode(&m); err != nil {
+			return nil
+		}
+		locales := make([]string, 0, len(m))
+		for l := range m {
+			locales = append(locales, l)
+		}
+		sort.Strings(locales)
+		out := make([]string, 0, len(m))
+		for _, l := range locales {
+			out = append(out, m[l])
+		}
+		return out
+	}
+	return nil
+}
+
+// camelCase converts the key to the go name, eg: NOT_FOUND => NotFound
+func camelCase(key string) string {
+	parts := strings.FieldsFunc(strings.ToLower(key), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	for i, p := range parts {
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// paramName returns the go parameter name of the placeholder
+func paramName(placeholder string) string {
+	if name, ok := paramNames[placeholder]; ok {
+		return name
+	}
+	name := strings.ToLower(placeholder[:1]) + placeholder[1:]
+	if strings.Contains(name, "_") {
+		name = camelCase(name)
+		name = strings.ToLower(name[:1]) + name[1:]
+	}
+	if token.IsKeyword(name) {
+		name += "Param"
+	}
+	return name
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGenerated fails if the generated code drifts from the api error file
+func TestGenerated(t *testing.T) {
+	want, err := generate("../../../../config/errors.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile("../errors_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Error("errors_gen.go is out of date, run: go generate ./internal/restful/errors")
+	}
+}
+
+func TestParamName(t *testing.T) {
+	for placeholder, want := range map[string]string{
+		"resource": "resource",
+		"error":    "err",
+		"user_id":  "userId",
+		"Name":     "name",
+		"type":     "typeParam",
+	} {
+		if got := paramName(placeholder); got != want {
+			t.Errorf("paramName(%q) = %q, want %q", placeholder, got, want)
+		}
+	}
+}
+
+func TestParamTypes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kingrest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		yaml string
+		want string
+		err  string
+	}{
+		{"USER_LOCKED:\n  code: 1001403\n  message: \"{name} is locked: {error}\"\n",
+			"func NewUserLocked(name string, err error) *APIError", ""},
+		{"USER_LOCKED:\n  code: 1001403\n  message: \"{name} is locked {times} times\"\n  params:\n    times: int\n",
+			"func NewUserLocked(name string, times int) *APIError", ""},
+		{"USER_LOCKED:\n  code: 1001403\n  message: \"{error}\"\n  params:\n    error: interface{}\n",
+			"func NewUserLocked(err interface{}) *APIError", ""},
+		{"USER_LOCKED:\n  code: 1001403\n  message: \"{times}\"\n  params:\n    times: uint8\n",
+			"", "unsupported type 'uint8'"},
+		{"USER_LOCKED:\n  code: 1001403\n  message: \"{name}\"\n  params:\n    times: int\n",
+			"", "not a placeholder"},
+	}
+	for _, c := range cases {
+		file := filepath.Join(dir, "errors.yaml")
+		if err = ioutil.WriteFile(file, []byte(c.yaml), 0644); err != nil {
+			t.Fatal(err)
+		}
+		src, err := generate(file)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("%q: got error %v, want %s", c.yaml, err, c.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", c.yaml, err)
+			continue
+		}
+		if !strings.Contains(string(src), c.want) {
+			t.Errorf("%q: got\n%s\nwant %s", c.yaml, src, c.want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package errors
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RequiredKeys the keys of the templates used by this package,
+// which must be defined in every api error file
+var RequiredKeys = []string{
+	KeyUnknownError,
+	KeyBadRequest,
+	KeyUnauthorized,
+	KeyNotFound,
+	KeyNotAcceptable,
+	KeyPreconditionFailed,
+	KeyPreconditionRequired,
+	KeyInternalServerError,
+}
+
+var placeholderRegexp = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// LintIssue a problem of the api error templates
+type LintIssue struct {
+	Key     string
+	Message string
+}
+
+func (i LintIssue) String() string {
+	if i.Key == "" {
+		return i.Message
+	}
+	return i.Key + ": " + i.Message
+}
+
+// Lint checks the api error file or directory, returns the issues sorted by key:
+//   - the required keys are missing, see RequiredKeys
+//   - the code is missing, or shared by several templates
+//   - the placeholders are malformed, eg: "{ id}" or "{id"
+//   - the placeholders of message are not in developer_message,
+//     or differ between the locales
+//   - the message is missing in some locales of the file
+//   - the code or http_status differ between the per-locale files
+func Lint(file string) ([]LintIssue, error) {
+	m, err := ParseMessages(file)
+	if err != nil {
+		return nil, err
+	}
+	return m.Lint(GetSettings().DefaultLocale), nil
+}
+
+// Lint checks the parsed templates, the messages without locale are
+// counted as the locale, default zh-CN, see Lint
+func (m *Messages) Lint(locale string) []LintIssue {
+	if locale == "" {
+		locale = defaultLocale
+	}
+	issues := append(lintTemplates(m.templates, locale), m.conflicts...)
+	sortIssues(issues)
+	return issues
+}
+
+func lintTemplates(t map[string]errorTemplate, def string) []LintIssue {
+	var issues []LintIssue
+	report := func(key, format string, args ...interface{}) {
+		issues = append(issues, LintIssue{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, key := range RequiredKeys {
+		if _, ok := t[key]; !ok {
+			report(key, "required key is missing")
+		}
+	}
+
+	// all locales of the file
+	allLocales := map[string]bool{}
+	for _, tpl := range t {
+		for _, l := range tpl.Message.locales(def) {
+			allLocales[l] = true
+		}
+	}
+
+	codes := map[int64][]string{}
+	for key, tpl := range t {
+		if tpl.Code == 0 {
+			report(key, "code is missing")
+		} else {
+			codes[tpl.Code] = append(codes[tpl.Code], key)
+		}
+
+		if len(tpl.Message) == 0 {
+			report(key, "message is missing")
+		}
+		for l := range allLocales {
+			if _, ok := tpl.Message.lookup(l, def); !ok && len(tpl.Message) > 0 {
+				report(key, "message is missing in locale %s", l)
+			}
+		}
+
+		msgParams := lintText(key, "message", tpl.Message, def, report)
+		devParams := lintText(key, "developer_message", tpl.DeveloperMessage, def, report)
+		if len(tpl.DeveloperMessage) > 0 {
+			for _, p := range msgParams {
+				if !contains(devParams, p) {
+					report(key, "placeholder {%s} of message is not in developer_message", p)
+				}
+			}
+		}
+	}
+
+	for code, keys := range codes {
+		if len(keys) > 1 {
+			sort.Strings(keys)
+			for _, key := range keys {
+				report(key, "code %d is duplicated by %s", code, strings.Join(keys, ", "))
+			}
+		}
+	}
+
+	sortIssues(issues)
+	return issues
+}
+
+// sortIssues sorts the issues by key, then by message
+func sortIssues(issues []LintIssue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Key != issues[j].Key {
+			return issues[i].Key < issues[j].Key
+		}
+		return issues[i].Message < issues[j].Message
+	})
+}
+
+// lintText checks the placeholders of every locale of the text,
+// returns the placeholders of all locales
+func lintText(key, field string, text localizedText, def string, report func(key, format string, args ...interface{})) []string {
+	var first, all []string
+	for i, l := range text.locales(def) {
+		v, _ := text.lookup(l, def)
+		if rest := placeholderRegexp.ReplaceAllString(v, ""); strings.ContainsAny(rest, "{}") {
+			report(key, "malformed placeholder in %s of locale %s: %q", field, l, v)
+		}
+		params := placeholders(v)
+		for _, p := range params {
+			if !contains(all, p) {
+				all = append(all, p)
+			}
+		}
+		if i == 0 {
+			first = params
+		} else if strings.Join(params, ",") != strings.Join(first, ",") {
+			report(key, "placeholders of %s differ between locales %s and %s", field, text.locales(def)[0], l)
+		}
+	}
+	sort.Strings(all)
+	return all
+}
+
+// placeholders returns the sorted unique placeholder names of the message
+func placeholders(message string) []string {
+	var names []string
+	for _, m := range placeholderRegexp.FindAllStringSubmatch(message, -1) {
+		if !contains(names, m[1]) {
+			names = append(names, m[1])
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package errors
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintTemplates(t *testing.T) {
+	tpls := map[string]errorTemplate{
+		"UNKNOWN_ERROR":         {Code: 1000300, Message: localizedText{"zh-CN": "未知错误", "en-US": "Unknown error"}},
+		"BAD_REQUEST":           {Code: 1000400, Message: localizedText{"zh-CN": "请求错误", "en-US": "Bad request"}},
+		"UNAUTHORIZED":          {Code: 1000401, Message: localizedText{"zh-CN": "认证失败", "en-US": "Unauthorized"}},
+		"NOT_FOUND":             {Code: 1000404, Message: localizedText{"zh-CN": "{resource}不存在", "en-US": "{resource} not found"}},
+		"NOT_ACCEPTABLE":        {Code: 1000406, Message: localizedText{"zh-CN": "不支持的格式", "en-US": "Not acceptable"}},
+		"PRECONDITION_FAILED":   {Code: 1000412, Message: localizedText{"zh-CN": "资源已被修改", "en-US": "Precondition failed"}},
+		"PRECONDITION_REQUIRED": {Code: 1000428, Message: localizedText{"zh-CN": "请求缺少前置条件", "en-US": "Precondition required"}},
+		"INTERNAL_SERVER_ERROR": {Code: 1000500, Message: localizedText{"zh-CN": "服务内部异常", "en-US": "Internal error"}},
+	}
+	if issues := lintTemplates(tpls, defaultLocale); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %v", issues)
+	}
+
+	delete(tpls, "UNKNOWN_ERROR")
+	tpls["USER_EXISTS"] = errorTemplate{
+		Code:             1000404,
+		Message:          localizedText{"zh-CN": "用户{name}已存在", "en-US": "User {name exists"},
+		DeveloperMessage: localizedText{"": "user {id} exists"},
+	}
+	tpls["USER_LOCKED"] = errorTemplate{Code: 1001403, Message: localizedText{"zh-CN": "用户已锁定"}}
+
+	want := []string{
+		"NOT_FOUND: code 1000404 is duplicated by NOT_FOUND, USER_EXISTS",
+		"UNKNOWN_ERROR: required key is missing",
+		"USER_EXISTS: code 1000404 is duplicated by NOT_FOUND, USER_EXISTS",
+		"USER_EXISTS: malformed placeholder in message of locale en-US",
+		"USER_EXISTS: placeholder {name} of message is not in developer_message",
+		"USER_EXISTS: placeholders of message differ between locales en-US and zh-CN",
+		"USER_LOCKED: message is missing in locale en-US",
+	}
+	issues := lintTemplates(tpls, defaultLocale)
+	if len(issues) != len(want) {
+		t.Fatalf("got %d issues %v, want %d", len(issues), issues, len(want))
+	}
+	for i, issue := range issues {
+		if !strings.HasPrefix(issue.String(), want[i]) {
+			t.Errorf("issue %d = %q, want %q", i, issue, want[i])
+		}
+	}
+}
+
+func TestLintLocaleFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kingrest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"en-US.yaml": "NOT_FOUND:\n  code: 1000404\n  message: \"{resource} not found\"\n" +
+			"USER_LOCKED:\n  code: 1001403\n  http_status: 403\n  message: \"User is locked\"\n",
+		"zh-CN.yaml": "NOT_FOUND:\n  code: 1000405\n  message: \"{resource}不存在\"\n" +
+			"USER_LOCKED:\n  http_status: 423\n  message: \"用户已锁定\"\n",
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := ParseMessages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the code and http_status of the first file are used
+	if tpl := m.templates["NOT_FOUND"]; tpl.Code != 1000404 {
+		t.Errorf("unexpected template %+v", tpl)
+	}
+	if tpl := m.templates["USER_LOCKED"]; tpl.Code != 1001403 || tpl.HTTPStatus != 403 {
+		t.Errorf("unexpected template %+v", tpl)
+	}
+
+	var got []string
+	for _, issue := range m.Lint("en-US") {
+		if issue.Key != "NOT_FOUND" && issue.Key != "USER_LOCKED" {
+			continue
+		}
+		got = append(got, issue.String())
+	}
+	want := []string{
+		"NOT_FOUND: code 1000405 of locale zh-CN differs from 1000404 of locale en-US",
+		"USER_LOCKED: http_status 423 of locale zh-CN differs from 403 of locale en-US",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got issues\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,177 @@
+package errors
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Locale returns the locale of the loaded messages best matching the Accept-Language,
+// it's the locale the api errors are localized in, see APIError.Localize
+func Locale(acceptLanguage string) string {
+	st := load()
+	available := localizedText{}
+	for _, t := range st.templates {
+		for _, l := range t.Message.locales(st.DefaultLocale) {
+			available[l] = l
+		}
+	}
+	if len(available) == 0 {
+		return st.DefaultLocale
+	}
+	return available.match(parseAcceptLanguage(acceptLanguage), st.DefaultLocale)
+}
+
+// localizedText message keyed by locale, the key of the plain message is ""
+type localizedText map[string]string
+
+// UnmarshalYAML accepts a plain message, or the messages keyed by locale, eg:
+//
+//	message: "资源不存在"
+//	message:
+//	  zh-CN: "资源不存在"
+//	  en-US: "Resource not found"
+func (t *localizedText) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*t = localizedText{"": value.Value}
+		return nil
+	}
+	m := map[string]string{}
+	if err := value.Decode(&m); err != nil {
+		return err
+	}
+	*t = m
+	return nil
+}
+
+// with returns the copy of t, merged with the plain message of other as the locale
+func (t localizedText) with(locale string, other localizedText) localizedText {
+	out := localizedText{}
+	for k, v := range t {
+		out[k] = v
+	}
+	for k, v := range other {
+		if k == "" {
+			k = locale
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// locales returns the sorted locales of the message, the plain message is
+// counted as the default locale
+func (t localizedText) locales(def string) []string {
+	seen := map[string]bool{}
+	for k := range t {
+		if k == "" {
+			k = def
+		}
+		seen[k] = true
+	}
+	locales := make([]string, 0, len(seen))
+	for k := range seen {
+		locales = append(locales, k)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// byLocale returns the messages keyed by locale, the plain message
+// is keyed by the default locale
+func (t localizedText) byLocale(def string) map[string]string {
+	if len(t) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(t))
+	for k, v := range t {
+		if k == "" {
+			k = def
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func (t localizedText) lookup(locale, def string) (string, bool) {
+	for k, v := range t {
+		if k == "" {
+			k = def
+		}
+		if strings.EqualFold(k, locale) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// match returns the message of the best matching locale: the exact locale,
+// the same language, eg: "en" or "en-GB" => "en-US", then the default locale
+func (t localizedText) match(locales []string, def string) string {
+	if len(t) == 0 {
+		return ""
+	}
+	for _, l := range locales {
+		if v, ok := t.lookup(l, def); ok {
+			return v
+		}
+	}
+	for _, l := range locales {
+		lang := language(l)
+		for _, k := range t.locales(def) {
+			if strings.EqualFold(language(k), lang) {
+				v, _ := t.lookup(k, def)
+				return v
+			}
+		}
+	}
+	if v, ok := t.lookup(def, def); ok {
+		return v
+	}
+	v, _ := t.lookup(t.locales(def)[0], def)
+	return v
+}
+
+func language(locale string) string {
+	return strings.SplitN(strings.Replace(locale, "_", "-", -1), "-", 2)[0]
+}
+
+// parseAcceptLanguage returns the locales of Accept-Language sorted by quality,
+// eg: "en-US,en;q=0.9,zh-CN;q=0.8" => [en-US en zh-CN]
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+	var ws []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		locale := strings.TrimSpace(fields[0])
+		if locale == "" || locale == "*" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				if v, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			ws = append(ws, weighted{locale, q})
+		}
+	}
+	sort.SliceStable(ws, func(i, j int) bool {
+		return ws[i].q > ws[j].q
+	})
+
+	locales := make([]string, 0, len(ws))
+	for _, w := range ws {
+		locales = append(locales, w.locale)
+	}
+	return locales
+}
@@ -0,0 +1,38 @@
+package errors
+
+import (
+	"testing"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	locales := parseAcceptLanguage("zh-CN;q=0.8, en-US,en;q=0.9,*;q=0.5,fr;q=0")
+	expected := []string{"en-US", "en", "zh-CN"}
+	if len(locales) != len(expected) {
+		t.Fatalf("unexpected locales: %v", locales)
+	}
+	for i := range expected {
+		if locales[i] != expected[i] {
+			t.Fatalf("unexpected locales: %v", locales)
+		}
+	}
+}
+
+func TestLocalizedTextMatch(t *testing.T) {
+	text := localizedText{"": "资源不存在", "en-US": "Resource not found"}
+
+	cases := map[string]string{
+		"":                "资源不存在",
+		"en-US":           "Resource not found",
+		"en-gb":           "Resource not found",
+		"fr,en;q=0.5":     "Resource not found",
+		"fr":              "资源不存在",
+		"zh-CN,en;q=0.5":  "资源不存在",
+		"zh-TW,en-US;q=1": "Resource not found",
+		"zh-TW":           "资源不存在",
+	}
+	for header, expected := range cases {
+		if msg := text.match(parseAcceptLanguage(header), "zh-CN"); msg != expected {
+			t.Errorf("Accept-Language '%s', expected '%s', got '%s'", header, expected, msg)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package errors
+
+import "strings"
+
+const (
+	// FormatDefault renders APIError as is
+	FormatDefault = "default"
+
+	// FormatProblem renders APIError as RFC 7807 problem details
+	FormatProblem = "problem"
+
+	// ProblemContentType content type of problem details
+	ProblemContentType = "application/problem+json"
+)
+
+// Problem RFC 7807 problem details, request_id, code and details are extension members
+type Problem struct {
+	Type      string      `json:"type"`
+	Title     string      `json:"title"`
+	Status    int         `json:"status"`
+	Detail    string      `json:"detail,omitempty"`
+	Instance  string      `json:"instance,omitempty"`
+	RequestId string      `json:"request_id,omitempty"`
+	Code      int64       `json:"code"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// Problem returns the problem details of the api error, `instance` is the request uri,
+// the type is the key under ProblemTypeBase, eg: NOT_FOUND => {ProblemTypeBase}/not-found
+func (e APIError) Problem(instance string) *Problem {
+	p := &Problem{
+		Type:      "about:blank",
+		Title:     e.Message,
+		Status:    e.HTTPStatus(),
+		Detail:    e.DeveloperMessage,
+		Instance:  instance,
+		RequestId: e.RequestId,
+		Code:      e.Code,
+		Details:   e.Details,
+	}
+	if base := GetSettings().ProblemTypeBase; base != "" && e.Key != "" {
+		p.Type = strings.TrimRight(base, "/") + "/" +
+			strings.ToLower(strings.Replace(e.Key, "_", "-", -1))
+	}
+	return p
+}
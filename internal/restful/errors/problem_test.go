@@ -0,0 +1,41 @@
+package errors
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestProblem(t *testing.T) {
+	defer SetFormat("", "")
+
+	e := APIError{
+		Key:              "USER_NOT_FOUND",
+		Code:             1000404,
+		Status:           http.StatusNotFound,
+		Message:          "user not found",
+		DeveloperMessage: "user 1 not found",
+		RequestId:        "abc",
+	}
+	cases := []struct {
+		base string
+		key  string
+		want string
+	}{
+		{"", "USER_NOT_FOUND", "about:blank"},
+		{"https://example.com/errors", "USER_NOT_FOUND", "https://example.com/errors/user-not-found"},
+		{"https://example.com/errors/", "USER_NOT_FOUND", "https://example.com/errors/user-not-found"},
+		{"https://example.com/errors", "", "about:blank"},
+	}
+	for _, c := range cases {
+		SetFormat(FormatProblem, c.base)
+		e.Key = c.key
+		p := e.Problem("/users/1?fields=id")
+		if p.Type != c.want {
+			t.Errorf("base %q, key %q: got type %s, want %s", c.base, c.key, p.Type, c.want)
+		}
+		if p.Title != e.Message || p.Detail != e.DeveloperMessage || p.Status != http.StatusNotFound ||
+			p.Instance != "/users/1?fields=id" || p.RequestId != "abc" || p.Code != 1000404 {
+			t.Errorf("unexpected problem %+v", p)
+		}
+	}
+}
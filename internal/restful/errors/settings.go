@@ -0,0 +1,119 @@
+package errors
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// the locale of the messages without locale if it's not configured
+const defaultLocale = "zh-CN"
+
+// Settings the settings of the api errors, they are swapped atomically
+// with the templates, see Apply
+type Settings struct {
+	// the operation mode, the developer messages are hidden in prod
+	Env string
+
+	// the locale of the messages without locale, and the fallback
+	// if none of the preferred locales matches, default zh-CN
+	DefaultLocale string
+
+	// respond the api errors with http status 200 for the legacy clients
+	LegacyStatus bool
+
+	// default format of api errors: default/problem
+	Format string
+
+	// base uri of the problem type, the type is "about:blank" if it's empty
+	ProblemTypeBase string
+}
+
+// Messages the parsed api error templates, see ParseMessages
+type Messages struct {
+	templates map[string]errorTemplate
+
+	// the code or http_status differing between the per-locale files
+	conflicts []LintIssue
+}
+
+// state the settings and the templates in effect, the requests read
+// the state once without lock, the reloading replaces it as a whole
+type state struct {
+	Settings
+	templates map[string]errorTemplate
+}
+
+var (
+	current atomic.Value
+
+	// serializes the writers of the state
+	writeLock = new(sync.Mutex)
+)
+
+func init() {
+	current.Store(&state{Settings: Settings{}.withDefaults()})
+}
+
+func (s Settings) withDefaults() Settings {
+	if s.DefaultLocale == "" {
+		s.DefaultLocale = defaultLocale
+	}
+	if s.Format == "" {
+		s.Format = FormatDefault
+	}
+	return s
+}
+
+func load() *state {
+	return current.Load().(*state)
+}
+
+// GetSettings returns the settings in effect
+func GetSettings() Settings {
+	return load().Settings
+}
+
+// Apply replaces the settings and the templates in one swap, the requests
+// see either the old or the new ones, the templates are kept if m is nil
+func Apply(s Settings, m *Messages) {
+	writeLock.Lock()
+	defer writeLock.Unlock()
+
+	next := &state{Settings: s.withDefaults(), templates: load().templates}
+	if m != nil {
+		next.templates = m.templates
+	}
+	current.Store(next)
+}
+
+// update replaces the settings by fn, the templates are kept
+func update(fn func(s *Settings)) {
+	writeLock.Lock()
+	defer writeLock.Unlock()
+
+	next := *load()
+	fn(&next.Settings)
+	next.Settings = next.Settings.withDefaults()
+	current.Store(&next)
+}
+
+func SetEnv(env string) {
+	update(func(s *Settings) { s.Env = env })
+}
+
+func SetLegacyStatus(legacy bool) {
+	update(func(s *Settings) { s.LegacyStatus = legacy })
+}
+
+func SetDefaultLocale(locale string) {
+	if locale != "" {
+		update(func(s *Settings) { s.DefaultLocale = locale })
+	}
+}
+
+func SetFormat(format, problemTypeBase string) {
+	update(func(s *Settings) {
+		s.Format = format
+		s.ProblemTypeBase = problemTypeBase
+	})
+}
@@ -3,6 +3,10 @@ package errors
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -12,52 +16,196 @@ type (
 	Params map[string]interface{}
 
 	errorTemplate struct {
-		Code             int64  `yaml:"code"`
-		Message          string `yaml:"message"`
-		DeveloperMessage string `yaml:"developer_message"`
+		Code             int64         `yaml:"code"`
+		HTTPStatus       int           `yaml:"http_status"`
+		Message          localizedText `yaml:"message"`
+		DeveloperMessage localizedText `yaml:"developer_message"`
 	}
 )
 
-var templates map[string]errorTemplate
-
+// LoadMessages loading the api error templates from a file, or a directory of
+// per-locale files named by the locale, eg: errors/zh-CN.yaml, errors/en-US.yaml.
+// the loaded templates are replaced only if all files are parsed successfully
 func LoadMessages(file string) error {
-	bytes, err := ioutil.ReadFile(file)
+	m, err := ParseMessages(file)
+	if err != nil {
+		return err
+	}
+	Apply(GetSettings(), m)
+	return nil
+}
+
+// ParseMessages parses the api error templates without applying them, see Apply
+func ParseMessages(file string) (*Messages, error) {
+	m := &Messages{}
+	fi, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		m.templates, err = parseMessageFile(file)
+	} else {
+		err = m.parseDir(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// parseDir parses the per-locale files of the directory, the code and
+// http_status of the first file defining them are used, the differences of
+// the other files are reported by Lint
+func (m *Messages) parseDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
 	if err != nil {
 		return err
 	}
-	templates = map[string]errorTemplate{}
-	return yaml.Unmarshal(bytes, &templates)
+	sort.Strings(paths)
+
+	m.templates = map[string]errorTemplate{}
+	// the locale of the file defining the code and http_status of each key
+	codeLocales := map[string]string{}
+	statusLocales := map[string]string{}
+	for _, path := range paths {
+		locale := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		lt, err := parseMessageFile(path)
+		if err != nil {
+			return err
+		}
+		for key, tpl := range lt {
+			merged := m.templates[key]
+			if tpl.Code != 0 {
+				if merged.Code == 0 {
+					merged.Code, codeLocales[key] = tpl.Code, locale
+				} else if tpl.Code != merged.Code {
+					m.conflicts = append(m.conflicts, LintIssue{Key: key, Message: fmt.Sprintf(
+						"code %d of locale %s differs from %d of locale %s", tpl.Code, locale, merged.Code, codeLocales[key])})
+				}
+			}
+			if tpl.HTTPStatus != 0 {
+				if merged.HTTPStatus == 0 {
+					merged.HTTPStatus, statusLocales[key] = tpl.HTTPStatus, locale
+				} else if tpl.HTTPStatus != merged.HTTPStatus {
+					m.conflicts = append(m.conflicts, LintIssue{Key: key, Message: fmt.Sprintf(
+						"http_status %d of locale %s differs from %d of locale %s", tpl.HTTPStatus, locale, merged.HTTPStatus, statusLocales[key])})
+				}
+			}
+			merged.Message = merged.Message.with(locale, tpl.Message)
+			merged.DeveloperMessage = merged.DeveloperMessage.with(locale, tpl.DeveloperMessage)
+			m.templates[key] = merged
+		}
+	}
+	return nil
+}
+
+func parseMessageFile(file string) (map[string]errorTemplate, error) {
+	bytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	t := map[string]errorTemplate{}
+	if err = yaml.Unmarshal(bytes, &t); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return t, nil
+}
+
+// Keys returns the sorted keys of the loaded error templates
+func Keys() []string {
+	return (&Messages{templates: load().templates}).Keys()
+}
+
+// Keys returns the sorted keys of the templates
+func (m *Messages) Keys() []string {
+	keys := make([]string, 0, len(m.templates))
+	for k := range m.templates {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
+// NewAPIError returns the api error of the template in the default locale,
+// see APIError.Localize
 func NewAPIError(code string, params Params) *APIError {
+	return NewLocalizedAPIError("", code, params)
+}
+
+// NewLocalizedAPIError returns the api error of the template in the locale best
+// matching the Accept-Language, eg: "en-US,en;q=0.9,zh-CN;q=0.8",
+// the first error of the params is the cause, see APIError.Unwrap
+func NewLocalizedAPIError(acceptLanguage string, code string, params Params) *APIError {
 	err := &APIError{
+		Key:     code,
+		Status:  http.StatusInternalServerError,
 		Message: code,
+		params:  params,
+		cause:   causeOf(params),
+		stack:   callers(),
 	}
+	err.render(parseAcceptLanguage(acceptLanguage))
+	return err
+}
 
-	if template, ok := templates[code]; ok {
-		err.Code = template.getErrorCode()
-		err.Message = template.getMessage(params)
+// fallbackStatus the http status of the keys used by this package, in case
+// they're missing in the api error file, see RequiredKeys
+var fallbackStatus = map[string]int{
+	KeyBadRequest:           http.StatusBadRequest,
+	KeyUnauthorized:         http.StatusUnauthorized,
+	KeyNotFound:             http.StatusNotFound,
+	KeyNotAcceptable:        http.StatusNotAcceptable,
+	KeyPreconditionFailed:   http.StatusPreconditionFailed,
+	KeyPreconditionRequired: http.StatusPreconditionRequired,
+	KeyInternalServerError:  http.StatusInternalServerError,
+}
 
-		if Env != "prod" {
-			err.DeveloperMessage = template.getDeveloperMessage(params)
+// render the messages of the template in the preferred locales, the error
+// without template has the fallback status and the code of the status,
+// eg: 406 => 1000406, the message is the key
+func (e *APIError) render(locales []string) {
+	st := load()
+	template, ok := st.templates[e.Key]
+	if !ok {
+		if s, ok := fallbackStatus[e.Key]; ok {
+			e.Status = s
 		}
+		e.Code = 1000000 + int64(e.Status)
+		return
 	}
+	e.Code = template.getErrorCode()
+	e.Status = template.getHTTPStatus()
+	e.Message = template.getMessage(locales, st.DefaultLocale, e.params)
 
-	return err
+	if st.Env != "prod" {
+		e.DeveloperMessage = template.getDeveloperMessage(locales, st.DefaultLocale, e.params)
+	}
 }
 
-func (e errorTemplate) getMessage(params Params) string {
-	return replacePlaceholders(e.Message, params)
+func (e errorTemplate) getMessage(locales []string, def string, params Params) string {
+	return replacePlaceholders(e.Message.match(locales, def), params)
 }
 
-func (e errorTemplate) getDeveloperMessage(params Params) string {
-	return replacePlaceholders(e.DeveloperMessage, params)
+func (e errorTemplate) getDeveloperMessage(locales []string, def string, params Params) string {
+	return replacePlaceholders(e.DeveloperMessage.match(locales, def), params)
 }
 
 func (e errorTemplate) getErrorCode() int64 {
 	return e.Code
 }
 
+// getHTTPStatus returns `http_status`, or the suffix of code if it's a 4xx/5xx
+// status, eg: 1000404 => 404, otherwise 500
+func (e errorTemplate) getHTTPStatus() int {
+	if e.HTTPStatus != 0 {
+		return e.HTTPStatus
+	}
+	if s := int(e.Code % 1000); s >= 400 && s <= 599 {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
 func replacePlaceholders(message string, params Params) string {
 	if len(message) == 0 {
 		return ""
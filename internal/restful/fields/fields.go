@@ -0,0 +1,204 @@
+package fields
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+)
+
+// the query parameters, eg: ?fields=id,name,roles.name&include=roles
+const (
+	ParamFields  = "fields"
+	ParamInclude = "include"
+)
+
+// the key of the selection in gin context
+const contextKey = "Fields"
+
+// Spec the allowlist of the fields and relations of an endpoint,
+// the fields not in the list are never responded
+type Spec struct {
+	// the json names of the fields, eg: id, name
+	Fields []string
+
+	// the relations can be included, keyed by the json name, eg: roles
+	Includes map[string]Include
+}
+
+// Include the relation can be included
+type Include struct {
+	// the gorm association to preload, eg: Roles
+	Association string
+
+	// the json names of the fields of the relation
+	Fields []string
+}
+
+// Selection the fields and relations selected by the request
+type Selection struct {
+	// the selected fields, and the fields of the included relations
+	Fields   []string
+	Includes map[string][]string
+
+	spec *Spec
+}
+
+// Parse parses ?fields= and ?include= of the request against the allowlist,
+// the selection is applied to the data of api.Success, see Get.
+// all the allowed fields are selected if ?fields= is absent
+func Parse(ctx *gin.Context, spec *Spec) (*Selection, error) {
+	s, err := spec.Parse(ctx.Query(ParamFields), ctx.Query(ParamInclude))
+	if err != nil {
+		return nil, errors.NewBadRequest(err)
+	}
+	ctx.Set(contextKey, s)
+	return s, nil
+}
+
+// Get returns the selection of the request, nil if it's not parsed
+func Get(ctx *gin.Context) *Selection {
+	if v, ok := ctx.Get(contextKey); ok {
+		if s, ok := v.(*Selection); ok {
+			return s
+		}
+	}
+	return nil
+}
+
+// Parse parses the comma separated fields and relations, the fields
+// of the relations are prefixed by the relation, eg: roles.name
+func (spec *Spec) Parse(fields, include string) (*Selection, error) {
+	s := &Selection{Includes: map[string][]string{}, spec: spec}
+
+	for _, name := range split(include) {
+		if _, ok := spec.Includes[name]; !ok {
+			return nil, fmt.Errorf("relation '%s' can't be included", name)
+		}
+		s.Includes[name] = nil
+	}
+
+	for _, name := range split(fields) {
+		parts := strings.SplitN(name, ".", 2)
+		if len(parts) == 1 {
+			if !contains(spec.Fields, name) {
+				return nil, fmt.Errorf("field '%s' can't be selected", name)
+			}
+			s.Fields = append(s.Fields, name)
+			continue
+		}
+
+		// the field of the relation includes it
+		inc, ok := spec.Includes[parts[0]]
+		if !ok {
+			return nil, fmt.Errorf("relation '%s' can't be included", parts[0])
+		}
+		if !contains(inc.Fields, parts[1]) {
+			return nil, fmt.Errorf("field '%s' can't be selected", name)
+		}
+		s.Includes[parts[0]] = append(s.Includes[parts[0]], parts[1])
+	}
+
+	if len(s.Fields) == 0 {
+		s.Fields = spec.Fields
+	}
+	for name, fs := range s.Includes {
+		if len(fs) == 0 {
+			s.Includes[name] = spec.Includes[name].Fields
+		}
+	}
+	return s, nil
+}
+
+// Preload preloads the associations of the included relations
+func (s *Selection) Preload(db *gorm.DB) *gorm.DB {
+	for name := range s.Includes {
+		db = db.Preload(s.spec.Includes[name].Association)
+	}
+	return db
+}
+
+// Trim returns the json tree of the data with the selected fields only,
+// the data is an object or a list of objects
+func (s *Selection) Trim(data interface{}) (interface{}, error) {
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+
+	var tree interface{}
+	if err = dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return s.trim(tree), nil
+}
+
+func (s *Selection) trim(tree interface{}) interface{} {
+	switch t := tree.(type) {
+	case []interface{}:
+		for i, item := range t {
+			t[i] = s.trim(item)
+		}
+		return t
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(s.Fields)+len(s.Includes))
+		for _, name := range s.Fields {
+			if v, ok := t[name]; ok {
+				out[name] = plain(v)
+			}
+		}
+		for name, fs := range s.Includes {
+			if v, ok := t[name]; ok {
+				out[name] = (&Selection{Fields: fs}).trim(v)
+			}
+		}
+		return out
+	}
+	return plain(tree)
+}
+
+// plain replaces json.Number with int64 or float64 for the other renderers
+func plain(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, item := range t {
+			t[k] = plain(item)
+		}
+	case []interface{}:
+		for i, item := range t {
+			t[i] = plain(item)
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	}
+	return v
+}
+
+func split(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
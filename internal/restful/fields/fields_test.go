@@ -0,0 +1,70 @@
+package fields
+
+import (
+	"reflect"
+	"testing"
+)
+
+type role struct {
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Secret string `json:"secret"`
+}
+
+type user struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	Age      int    `json:"age"`
+	Password string `json:"password"`
+	Roles    []role `json:"roles"`
+}
+
+var spec = &Spec{
+	Fields: []string{"id", "name", "age"},
+	Includes: map[string]Include{
+		"roles": {Association: "Roles", Fields: []string{"id", "name"}},
+	},
+}
+
+func TestTrim(t *testing.T) {
+	users := []user{{Id: "1", Name: "admin", Age: 18, Password: "secret",
+		Roles: []role{{Id: "r1", Name: "root", Secret: "secret"}}}}
+
+	cases := []struct {
+		fields, include string
+		want            interface{}
+	}{
+		{"", "", []interface{}{map[string]interface{}{"id": "1", "name": "admin", "age": int64(18)}}},
+		{"id, name", "", []interface{}{map[string]interface{}{"id": "1", "name": "admin"}}},
+		{"id", "roles", []interface{}{map[string]interface{}{"id": "1",
+			"roles": []interface{}{map[string]interface{}{"id": "r1", "name": "root"}}}}},
+		{"id,roles.name", "", []interface{}{map[string]interface{}{"id": "1",
+			"roles": []interface{}{map[string]interface{}{"name": "root"}}}}},
+	}
+	for _, c := range cases {
+		s, err := spec.Parse(c.fields, c.include)
+		if err != nil {
+			t.Fatalf("%s %s: %v", c.fields, c.include, err)
+		}
+		got, err := s.Trim(users)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s %s: got %#v, want %#v", c.fields, c.include, got, c.want)
+		}
+	}
+}
+
+func TestParseNotAllowed(t *testing.T) {
+	for _, c := range [][2]string{
+		{"password", ""},
+		{"roles.secret", ""},
+		{"", "permissions"},
+		{"permissions.id", ""},
+	} {
+		if _, err := spec.Parse(c[0], c[1]); err == nil {
+			t.Errorf("fields=%s include=%s: expect error", c[0], c[1])
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+)
+
+// the query parameters, eg:
+//
+//	?filter=name eq "admin" and created_at gt "2020-01-01"&sort=-created_at,name
+const (
+	ParamFilter = "filter"
+	ParamSort   = "sort"
+)
+
+// the value types of the fields
+const (
+	TypeString = "string"
+	TypeNumber = "number"
+	TypeBool   = "bool"
+	TypeTime   = "time"
+)
+
+var (
+	// StringOps the operators of the string fields
+	StringOps = []string{OpEq, OpNe, OpIn, OpContains, OpStartsWith}
+
+	// CompareOps the operators of the number and time fields
+	CompareOps = []string{OpEq, OpNe, OpGt, OpGe, OpLt, OpLe, OpIn}
+)
+
+// the time layouts of the time values
+var timeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// Spec the whitelist of the fields can be filtered and sorted
+type Spec struct {
+	// keyed by the field name in the api, eg: created_at
+	Fields map[string]Field
+
+	// the sort if it's not specified, eg: -created_at
+	DefaultSort string
+}
+
+// Field the filterable or sortable field
+type Field struct {
+	// the column of the table, default the field name
+	Column string
+
+	// TypeString (default), TypeNumber, TypeBool or TypeTime
+	Type string
+
+	// the allowed comparison operators, none means it can't be filtered
+	Ops []string
+
+	Sortable bool
+}
+
+// Query the parsed filter and sort
+type Query struct {
+	Filter Node
+	Sort   []Sort
+
+	spec *Spec
+}
+
+// Sort the sort field, eg: -created_at
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Error the invalid filter or sort expression, used as the details of BAD_REQUEST
+type Error struct {
+	Param string `json:"param"`
+
+	// offset of the expression
+	Position int    `json:"position"`
+	Message  string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid %s at %d: %s", e.Param, e.Position, e.Message)
+}
+
+// Parse parses and validates the filter and sort of the request against the spec,
+// the error is BAD_REQUEST with the details of the invalid expression
+func Parse(ctx *gin.Context, spec *Spec) (*Query, error) {
+	q, err := spec.Parse(ctx.Query(ParamFilter), ctx.Query(ParamSort))
+	if err != nil {
+		e := errors.NewBadRequest(err)
+		if fe, ok := err.(*Error); ok {
+			e.Details = []*Error{fe}
+		}
+		return nil, e
+	}
+	return q, nil
+}
+
+// Parse parses and validates the filter and sort expressions
+func (s *Spec) Parse(filter, sort string) (*Query, error) {
+	n, err := ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if n != nil {
+		if err = s.validate(n); err != nil {
+			return nil, err
+		}
+	}
+
+	if strings.TrimSpace(sort) == "" {
+		sort = s.DefaultSort
+	}
+	sorts, err := s.parseSort(sort)
+	if err != nil {
+		return nil, err
+	}
+	return &Query{Filter: n, Sort: sorts, spec: s}, nil
+}
+
+// parseSort parses the comma separated fields, descending with the '-' prefix
+func (s *Spec) parseSort(expr string) ([]Sort, error) {
+	var sorts []Sort
+	pos := 0
+	for _, part := range strings.Split(expr, ",") {
+		name := strings.TrimSpace(part)
+		if name != "" {
+			sort := Sort{Field: strings.TrimLeft(name, "+-"), Desc: strings.HasPrefix(name, "-")}
+			if f, ok := s.Fields[sort.Field]; !ok || !f.Sortable {
+				return nil, &Error{Param: ParamSort, Position: pos, Message: fmt.Sprintf("field '%s' can't be sorted", sort.Field)}
+			}
+			sorts = append(sorts, sort)
+		}
+		pos += len(part) + 1
+	}
+	return sorts, nil
+}
+
+// validate checks the fields, operators and value types of the comparisons
+func (s *Spec) validate(n Node) error {
+	switch t := n.(type) {
+	case *Logical:
+		if err := s.validate(t.Left); err != nil {
+			return err
+		}
+		return s.validate(t.Right)
+	case *Not:
+		return s.validate(t.Expr)
+	case *Comparison:
+		f, ok := s.Fields[t.Field]
+		if !ok || len(f.Ops) == 0 {
+			return &Error{Param: ParamFilter, Position: t.pos, Message: fmt.Sprintf("field '%s' can't be filtered", t.Field)}
+		}
+		if !contains(f.Ops, t.Op) {
+			return &Error{Param: ParamFilter, Position: t.pos, Message: fmt.Sprintf("operator '%s' is not allowed on '%s'", t.Op, t.Field)}
+		}
+		values := t.Values
+		if t.Op != OpIn {
+			values = []interface{}{t.Value}
+		}
+		for i, v := range values {
+			if v == nil && (t.Op == OpEq || t.Op == OpNe) {
+				continue
+			}
+			cv, err := f.convert(t.Op, v)
+			if err != nil {
+				return &Error{Param: ParamFilter, Position: t.pos, Message: fmt.Sprintf("'%s': %v", t.Field, err)}
+			}
+			values[i] = cv
+		}
+		if t.Op != OpIn {
+			t.Value = values[0]
+		}
+	}
+	return nil
+}
+
+// convert checks the value of the field type, the time string is parsed
+func (f Field) convert(op string, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, fmt.Errorf("null is only allowed with eq and ne")
+	}
+	if op == OpContains || op == OpStartsWith {
+		if _, ok := v.(string); !ok {
+			return nil, fmt.Errorf("%s expects a string", op)
+		}
+		return v, nil
+	}
+
+	switch f.Type {
+	case TypeNumber:
+		if _, ok := v.(float64); !ok {
+			return nil, fmt.Errorf("expect a number")
+		}
+	case TypeBool:
+		if _, ok := v.(bool); !ok {
+			return nil, fmt.Errorf("expect true or false")
+		}
+	case TypeTime:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expect a time string")
+		}
+		for _, layout := range timeLayouts {
+			if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid time '%s', eg: 2020-01-01 or %s", s, time.RFC3339)
+	default:
+		if _, ok := v.(string); !ok {
+			return nil, fmt.Errorf("expect a string")
+		}
+	}
+	return v, nil
+}
+
+// Apply adds the where and order clauses of the query, the columns are
+// only from the spec and the values are bound as parameters
+func (q *Query) Apply(db *gorm.DB) *gorm.DB {
+	if q.Filter != nil {
+		sql, args := q.compile(q.Filter)
+		db = db.Where(sql, args...)
+	}
+	for _, s := range q.Sort {
+		order := q.spec.column(s.Field)
+		if s.Desc {
+			order += " desc"
+		}
+		db = db.Order(order)
+	}
+	return db
+}
+
+var sqlOps = map[string]string{
+	OpEq: "=", OpNe: "<>", OpGt: ">", OpGe: ">=", OpLt: "<", OpLe: "<=",
+}
+
+func (q *Query) compile(n Node) (string, []interface{}) {
+	switch t := n.(type) {
+	case *Logical:
+		left, largs := q.compile(t.Left)
+		right, rargs := q.compile(t.Right)
+		return "(" + left + " " + strings.ToUpper(t.Op) + " " + right + ")", append(largs, rargs...)
+	case *Not:
+		sql, args := q.compile(t.Expr)
+		return "NOT (" + sql + ")", args
+	case *Comparison:
+		column := q.spec.column(t.Field)
+		switch t.Op {
+		case OpIn:
+			return column + " IN (?)", []interface{}{t.Values}
+		case OpContains:
+			return column + " LIKE ?", []interface{}{"%" + escapeLike(t.Value.(string)) + "%"}
+		case OpStartsWith:
+			return column + " LIKE ?", []interface{}{escapeLike(t.Value.(string)) + "%"}
+		}
+		if t.Value == nil {
+			if t.Op == OpNe {
+				return column + " IS NOT NULL", nil
+			}
+			return column + " IS NULL", nil
+		}
+		return column + " " + sqlOps[t.Op] + " ?", []interface{}{t.Value}
+	}
+	return "", nil
+}
+
+func (s *Spec) column(field string) string {
+	if c := s.Fields[field].Column; c != "" {
+		return c
+	}
+	return field
+}
+
+// escapeLike escapes the wildcards of LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
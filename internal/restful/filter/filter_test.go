@@ -0,0 +1,86 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var spec = &Spec{
+	Fields: map[string]Field{
+		"id":         {Ops: []string{OpEq, OpIn}},
+		"name":       {Ops: StringOps, Sortable: true},
+		"age":        {Type: TypeNumber, Ops: CompareOps},
+		"created_at": {Column: "users.created_at", Type: TypeTime, Ops: CompareOps, Sortable: true},
+	},
+	DefaultSort: "-created_at",
+}
+
+func TestCompile(t *testing.T) {
+	cases := []struct {
+		filter string
+		sql    string
+		args   []interface{}
+	}{
+		{`name eq "admin"`, "name = ?", []interface{}{"admin"}},
+		{`name eq "a" and age gt 18 or not id in ("1", '2')`,
+			"((name = ? AND age > ?) OR NOT (id IN (?)))",
+			[]interface{}{"a", 18.0, []interface{}{"1", "2"}}},
+		{`name eq "a" and (age lt 1 or age ge 60)`,
+			"(name = ? AND (age < ? OR age >= ?))", []interface{}{"a", 1.0, 60.0}},
+		{`name contains "50%_off" AND name ne null`,
+			`(name LIKE ? AND name IS NOT NULL)`, []interface{}{`%50\%\_off%`}},
+		{`created_at gt "2020-01-02"`, "users.created_at > ?",
+			[]interface{}{time.Date(2020, 1, 2, 0, 0, 0, 0, time.Local)}},
+	}
+	for _, c := range cases {
+		q, err := spec.Parse(c.filter, "")
+		if err != nil {
+			t.Fatalf("%s: %v", c.filter, err)
+		}
+		sql, args := q.compile(q.Filter)
+		if sql != c.sql || !reflect.DeepEqual(args, c.args) {
+			t.Errorf("%s: got %s %v, want %s %v", c.filter, sql, args, c.sql, c.args)
+		}
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	cases := []struct {
+		filter, sort string
+		param        string
+		position     int
+	}{
+		{`password eq "x"`, "", ParamFilter, 0},
+		{`name gt "x"`, "", ParamFilter, 0},
+		{`name eq "x" and age eq "old"`, "", ParamFilter, 16},
+		{`name eq "x" and`, "", ParamFilter, 15},
+		{`(name eq "x"`, "", ParamFilter, 12},
+		{`name eq "x`, "", ParamFilter, 8},
+		{`name eq "x"; drop table users`, "", ParamFilter, 11},
+		{`age gt null`, "", ParamFilter, 0},
+		{"", "name,-age", ParamSort, 5},
+	}
+	for _, c := range cases {
+		_, err := spec.Parse(c.filter, c.sort)
+		e, ok := err.(*Error)
+		if !ok {
+			t.Errorf("%q %q: expect *Error, got %v", c.filter, c.sort, err)
+			continue
+		}
+		if e.Param != c.param || e.Position != c.position {
+			t.Errorf("%q %q: got %v", c.filter, c.sort, e)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	q, err := spec.Parse("", "")
+	if err != nil || !reflect.DeepEqual(q.Sort, []Sort{{Field: "created_at", Desc: true}}) {
+		t.Errorf("default sort = %v, %v", q, err)
+	}
+	q, err = spec.Parse("", "name, -created_at")
+	if err != nil || !reflect.DeepEqual(q.Sort, []Sort{{Field: "name"}, {Field: "created_at", Desc: true}}) {
+		t.Errorf("sort = %v, %v", q, err)
+	}
+}
@@ -0,0 +1,323 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// the comparison operators
+const (
+	OpEq         = "eq"
+	OpNe         = "ne"
+	OpGt         = "gt"
+	OpGe         = "ge"
+	OpLt         = "lt"
+	OpLe         = "le"
+	OpIn         = "in"
+	OpContains   = "contains"
+	OpStartsWith = "startswith"
+)
+
+// the logical operators
+const (
+	OpAnd = "and"
+	OpOr  = "or"
+	OpNot = "not"
+)
+
+const (
+	// the max length of the filter expression
+	maxLength = 1024
+
+	// the max nesting depth of the parentheses and not
+	maxDepth = 16
+)
+
+var comparisonOps = map[string]bool{
+	OpEq: true, OpNe: true, OpGt: true, OpGe: true, OpLt: true, OpLe: true,
+	OpIn: true, OpContains: true, OpStartsWith: true,
+}
+
+// Node the node of the filter expression AST
+type Node interface {
+	node()
+}
+
+// Logical the and/or of the expressions
+type Logical struct {
+	Op          string
+	Left, Right Node
+}
+
+// Not the negation of the expression
+type Not struct {
+	Expr Node
+}
+
+// Comparison compares the field with the value, or the values of `in`,
+// the value is string, float64, bool or nil
+type Comparison struct {
+	Field  string
+	Op     string
+	Value  interface{}
+	Values []interface{}
+
+	// offset of the field in the expression
+	pos int
+}
+
+func (Logical) node()    {}
+func (Not) node()        {}
+func (Comparison) node() {}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// ParseFilter parses the filter expression to AST, eg:
+//
+//	name eq "admin" and (created_at gt "2020-01-01" or not id in ("1", "2"))
+func ParseFilter(expr string) (Node, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	if len(expr) > maxLength {
+		return nil, &Error{Param: ParamFilter, Message: fmt.Sprintf("longer than %d characters", maxLength)}
+	}
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected '%s'", t.text)
+	}
+	return n, nil
+}
+
+type parser struct {
+	tokens []token
+	i      int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+	return t
+}
+
+// keyword whether the next token is the keyword, case-insensitive
+func (p *parser) keyword(kw string) bool {
+	t := p.peek()
+	return t.kind == tokenIdent && strings.EqualFold(t.text, kw)
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	if t.kind == tokenEOF {
+		format = "unexpected end, " + format
+	}
+	return &Error{Param: ParamFilter, Position: t.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseOr(depth int) (Node, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword(OpOr) {
+		p.next()
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: OpOr, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd(depth int) (Node, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword(OpAnd) {
+		p.next()
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: OpAnd, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary(depth int) (Node, error) {
+	if depth >= maxDepth {
+		return nil, p.errorf(p.peek(), "nested deeper than %d", maxDepth)
+	}
+	if p.keyword(OpNot) {
+		p.next()
+		n, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: n}, nil
+	}
+	if p.peek().kind == tokenLParen {
+		p.next()
+		n, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokenRParen {
+			return nil, p.errorf(t, "expect ')'")
+		}
+		return n, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	field := p.next()
+	if field.kind != tokenIdent {
+		return nil, p.errorf(field, "expect field name")
+	}
+	opToken := p.next()
+	op := strings.ToLower(opToken.text)
+	if opToken.kind != tokenIdent || !comparisonOps[op] {
+		return nil, p.errorf(opToken, "expect operator after '%s'", field.text)
+	}
+
+	c := &Comparison{Field: field.text, Op: op, pos: field.pos}
+	if op != OpIn {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		c.Value = v
+		return c, nil
+	}
+
+	if t := p.next(); t.kind != tokenLParen {
+		return nil, p.errorf(t, "expect '(' after in")
+	}
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		c.Values = append(c.Values, v)
+		t := p.next()
+		if t.kind == tokenRParen {
+			break
+		}
+		if t.kind != tokenComma {
+			return nil, p.errorf(t, "expect ',' or ')'")
+		}
+	}
+	return c, nil
+}
+
+func (p *parser) parseValue() (interface{}, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return t.text, nil
+	case tokenNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, p.errorf(t, "invalid number '%s'", t.text)
+		}
+		return f, nil
+	case tokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	}
+	return nil, p.errorf(t, "expect value")
+}
+
+func lex(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+		case r == '"' || r == '\'':
+			start := i
+			var sb strings.Builder
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, &Error{Param: ParamFilter, Position: start, Message: "unterminated string"}
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					sb.WriteRune(runes[i])
+					continue
+				}
+				if runes[i] == r {
+					break
+				}
+				sb.WriteRune(runes[i])
+			}
+			i++
+			tokens = append(tokens, token{tokenString, sb.String(), start})
+		case r == '-' || r == '+' || unicode.IsDigit(r):
+			start := i
+			for i++; i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.'); i++ {
+			}
+			tokens = append(tokens, token{tokenNumber, string(runes[start:i]), start})
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i++; i < len(runes) && (runes[i] == '_' || runes[i] == '.' ||
+				unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])); i++ {
+			}
+			tokens = append(tokens, token{tokenIdent, string(runes[start:i]), start})
+		default:
+			return nil, &Error{Param: ParamFilter, Position: i, Message: fmt.Sprintf("unexpected '%c'", r)}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
@@ -0,0 +1,202 @@
+package restful
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/zliang90/kingRest/pkg/log"
+)
+
+const (
+	// the listener names of systemd socket activation, see FileDescriptorName
+	listenerWeb   = "web"
+	listenerAdmin = "admin"
+
+	unixScheme = "unix://"
+
+	// the first file descriptor passed by systemd
+	listenFdsStart = 3
+)
+
+var (
+	inherited     map[string]net.Listener
+	inheritedOnce sync.Once
+
+	// the listeners in use, handed off to the new process on upgrade
+	active     = make(map[string]net.Listener)
+	activeLock = new(sync.Mutex)
+)
+
+// listen returns the listener of the name, the listener inherited from systemd
+// socket activation is preferred, otherwise listen on the address:
+//
+//	127.0.0.1:8086              tcp
+//	unix:///run/kingrest.sock   unix domain socket, its file mode is set to `mode`, eg: "0660"
+func listen(name, addr, mode string) (net.Listener, error) {
+	l, err := newListener(name, addr, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	activeLock.Lock()
+	defer activeLock.Unlock()
+
+	active[name] = l
+	return l, nil
+}
+
+func newListener(name, addr, mode string) (net.Listener, error) {
+	inheritedOnce.Do(func() {
+		var err error
+		if inherited, err = inheritListeners(); err != nil {
+			log.Errorf("inherit listeners failed: %v", err)
+		}
+	})
+	if l, ok := inherited[name]; ok {
+		log.Infof("use the inherited listener of '%s': %s", name, l.Addr())
+		return l, nil
+	}
+
+	if !strings.HasPrefix(addr, unixScheme) {
+		return net.Listen("tcp", addr)
+	}
+
+	path := strings.TrimPrefix(addr, unixScheme)
+	// the socket file may be served by the parent process or systemd
+	if len(inherited) == 0 {
+		if err := removeStaleSocket(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if mode != "" {
+		m, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil {
+			l.Close()
+			return nil, fmt.Errorf("invalid socket mode '%s', %v", mode, err)
+		}
+		if err = os.Chmod(path, os.FileMode(m)); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// removeStaleSocket removes the socket file left by the crashed process,
+// the socket is stale only if nobody accepts the connection on it
+func removeStaleSocket(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return nil
+	}
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("socket '%s' is in use by another process", path)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return nil
+	}
+	log.Infof("remove the stale socket: %s", path)
+	return os.Remove(path)
+}
+
+// inheritListeners returns the listeners passed by the parent process on upgrade
+// (KINGREST_LISTEN_FDNAMES) or by systemd (LISTEN_FDS), the systemd ones are
+// matched by LISTEN_FDNAMES, or by order "web", "admin" if none of the names
+// is known
+func inheritListeners() (map[string]net.Listener, error) {
+	if v, ok := os.LookupEnv(envUpgradeFdNames); ok {
+		os.Unsetenv(envUpgradeFdNames)
+		return fileListeners(strings.Split(v, ":"))
+	}
+
+	names := systemdNames()
+	if len(names) == 0 {
+		return map[string]net.Listener{}, nil
+	}
+	return fileListeners(listenerNames(names, []string{listenerWeb, listenerAdmin}))
+}
+
+// systemdNames returns the names of the file descriptors passed by systemd,
+// they're empty if LISTEN_FDNAMES is not set, the variables are removed so
+// that the listeners are not inherited by the child processes
+func systemdNames() []string {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil
+	}
+	names := make([]string, n)
+	if v := os.Getenv("LISTEN_FDNAMES"); v != "" {
+		copy(names, strings.Split(v, ":"))
+	}
+
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	return names
+}
+
+// listenerNames returns the listener names of the file descriptors, if none
+// of the names is in `defaults`, eg: the names are the socket unit names,
+// the file descriptors are named by `defaults` in order, the file
+// descriptors without name are closed
+func listenerNames(names, defaults []string) []string {
+	named := false
+	for _, name := range names {
+		for _, d := range defaults {
+			if name == d {
+				named = true
+			}
+		}
+	}
+
+	out := make([]string, len(names))
+	for i, name := range names {
+		switch {
+		case !named && i < len(defaults):
+			out[i] = defaults[i]
+		case named:
+			for _, d := range defaults {
+				if name == d {
+					out[i] = name
+				}
+			}
+		}
+	}
+	return out
+}
+
+// fileListeners returns the listeners of the file descriptors starting from 3,
+// the file descriptors without name are closed
+func fileListeners(names []string) (map[string]net.Listener, error) {
+	listeners := make(map[string]net.Listener)
+	for i, name := range names {
+		f := os.NewFile(uintptr(listenFdsStart+i), name)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return listeners, fmt.Errorf("fd %d is not a listener, %v", listenFdsStart+i, err)
+		}
+		if name == "" {
+			l.Close()
+			continue
+		}
+		listeners[name] = l
+	}
+	return listeners, nil
+}
@@ -0,0 +1,139 @@
+package restful
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSystemdNames(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	cases := []struct {
+		pid, fds, fdNames string
+		want              []string
+	}{
+		{pid, "2", "web:admin", []string{"web", "admin"}},
+		{pid, "2", "", []string{"", ""}},
+		{pid, "3", "kingrest.socket", []string{"kingrest.socket", "", ""}},
+		{pid, "1", "web:admin", []string{"web"}},
+		{pid, "0", "", nil},
+		{pid, "x", "", nil},
+		{"1", "2", "web:admin", nil},
+		{"", "2", "web:admin", nil},
+	}
+	for _, c := range cases {
+		os.Setenv("LISTEN_PID", c.pid)
+		os.Setenv("LISTEN_FDS", c.fds)
+		os.Setenv("LISTEN_FDNAMES", c.fdNames)
+
+		if got := systemdNames(); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%+v: got %q, want %q", c, got, c.want)
+		}
+		if c.want != nil {
+			for _, k := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+				if _, ok := os.LookupEnv(k); ok {
+					t.Errorf("%+v: %s is not removed", c, k)
+				}
+			}
+		}
+	}
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+}
+
+func TestListenerNames(t *testing.T) {
+	defaults := []string{listenerWeb, listenerAdmin}
+	cases := []struct {
+		names []string
+		want  []string
+	}{
+		{[]string{"web", "admin"}, []string{"web", "admin"}},
+		{[]string{"admin", "web"}, []string{"admin", "web"}},
+		{[]string{"admin"}, []string{"admin"}},
+		// named by order without names
+		{[]string{"", ""}, []string{"web", "admin"}},
+		{[]string{"unknown", "unknown"}, []string{"web", "admin"}},
+		// the socket unit names are not known
+		{[]string{"kingrest.socket", "kingrest-admin.socket"}, []string{"web", "admin"}},
+		{[]string{"kingrest.socket", "", "extra"}, []string{"web", "admin", ""}},
+		// the unknown names are closed if any name is known
+		{[]string{"metrics", "web"}, []string{"", "web"}},
+		{[]string{"web", "unknown"}, []string{"web", ""}},
+	}
+	for _, c := range cases {
+		if got := listenerNames(c.names, defaults); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: got %q, want %q", c.names, got, c.want)
+		}
+	}
+}
+
+func TestRemoveStaleSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kingrest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// don't inherit the listeners of the test process
+	inheritedOnce.Do(func() {})
+	defer func() { inherited = nil }()
+
+	path := filepath.Join(dir, "kingrest.sock")
+	addr := unixScheme + path
+
+	// the socket file left by the crashed process
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	// the stale socket is kept if the listeners are inherited
+	inherited = map[string]net.Listener{listenerAdmin: nil}
+	if _, err = newListener(listenerWeb, addr, ""); err == nil {
+		t.Fatal("expected error of listening on the existing socket")
+	}
+	if _, err = os.Stat(path); err != nil {
+		t.Fatalf("the socket is removed with the inherited listeners: %v", err)
+	}
+	inherited = nil
+
+	l, err := newListener(listenerWeb, addr, "0600")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("unexpected socket file: %v %v", fi, err)
+	}
+
+	// the socket in use is not removed
+	_, err = newListener(listenerWeb, addr, "")
+	if err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Fatalf("expected error of the socket in use, got %v", err)
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("the socket in use is removed: %v", err)
+	}
+	conn.Close()
+
+	// not a socket
+	file := filepath.Join(dir, "file")
+	if err = ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = newListener(listenerWeb, unixScheme+file, ""); err == nil {
+		t.Error("expected error of listening on the regular file")
+	}
+	if _, err = os.Stat(file); err != nil {
+		t.Errorf("the regular file is removed: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package restful
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// request metrics of the restful api, exposed in prometheus text format
+type requestMetrics struct {
+	mu       sync.Mutex
+	counters map[metricKey]*metricValue
+	inFlight int64
+}
+
+type metricKey struct {
+	method string
+	path   string
+	status int
+}
+
+type metricValue struct {
+	count    uint64
+	duration float64
+}
+
+var metrics = &requestMetrics{counters: make(map[metricKey]*metricValue)}
+
+func handlerMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		atomic.AddInt64(&metrics.inFlight, 1)
+		defer atomic.AddInt64(&metrics.inFlight, -1)
+
+		c.Next()
+
+		path := c.FullPath()
+		if path == "" {
+			path = "NoRoute"
+		}
+		metrics.observe(metricKey{c.Request.Method, path, c.Writer.Status()}, time.Since(start))
+	}
+}
+
+func (m *requestMetrics) observe(k metricKey, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.counters[k]
+	if !ok {
+		v = new(metricValue)
+		m.counters[k] = v
+	}
+	v.count++
+	v.duration += d.Seconds()
+}
+
+// writeTo write the metrics in prometheus text format
+func (m *requestMetrics) writeTo(w io.Writer) {
+	m.mu.Lock()
+	keys := make([]metricKey, 0, len(m.counters))
+	values := make(map[metricKey]metricValue, len(m.counters))
+	for k, v := range m.counters {
+		keys = append(keys, k)
+		values[k] = *v
+	}
+	m.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].path != keys[j].path {
+			return keys[i].path < keys[j].path
+		}
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].status < keys[j].status
+	})
+
+	fmt.Fprintln(w, "# TYPE kingrest_http_requests_total counter")
+	for _, k := range keys {
+		fmt.Fprintf(w, "kingrest_http_requests_total%s %d\n", k.labels(), values[k].count)
+	}
+	fmt.Fprintln(w, "# TYPE kingrest_http_request_duration_seconds_sum counter")
+	for _, k := range keys {
+		fmt.Fprintf(w, "kingrest_http_request_duration_seconds_sum%s %g\n", k.labels(), values[k].duration)
+	}
+	fmt.Fprintln(w, "# TYPE kingrest_http_requests_in_flight gauge")
+	fmt.Fprintf(w, "kingrest_http_requests_in_flight %d\n", atomic.LoadInt64(&m.inFlight))
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	fmt.Fprintln(w, "# TYPE go_goroutines gauge")
+	fmt.Fprintf(w, "go_goroutines %d\n", runtime.NumGoroutine())
+	fmt.Fprintln(w, "# TYPE go_memstats_heap_alloc_bytes gauge")
+	fmt.Fprintf(w, "go_memstats_heap_alloc_bytes %d\n", ms.HeapAlloc)
+}
+
+func (k metricKey) labels() string {
+	return fmt.Sprintf(`{method=%s,path=%s,status="%d"}`,
+		strconv.Quote(k.method), strconv.Quote(k.path), k.status)
+}
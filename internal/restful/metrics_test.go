@@ -0,0 +1,44 @@
+package restful
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsWriteTo(t *testing.T) {
+	m := &requestMetrics{counters: make(map[metricKey]*metricValue), inFlight: 1}
+	m.observe(metricKey{"GET", "/api/v1/users/:id", 200}, 100*time.Millisecond)
+	m.observe(metricKey{"GET", "/api/v1/users/:id", 200}, 150*time.Millisecond)
+	m.observe(metricKey{"PATCH", "/api/v1/users/:id", 412}, 50*time.Millisecond)
+	m.observe(metricKey{"GET", "/api/v1/users", 200}, time.Second)
+	m.observe(metricKey{"GET", `NoRoute"\`, 404}, 0)
+
+	var buf bytes.Buffer
+	m.writeTo(&buf)
+	out := buf.String()
+
+	// sorted by path, method and status, with the escaped labels
+	want := `# TYPE kingrest_http_requests_total counter
+kingrest_http_requests_total{method="GET",path="/api/v1/users",status="200"} 1
+kingrest_http_requests_total{method="GET",path="/api/v1/users/:id",status="200"} 2
+kingrest_http_requests_total{method="PATCH",path="/api/v1/users/:id",status="412"} 1
+kingrest_http_requests_total{method="GET",path="NoRoute\"\\",status="404"} 1
+# TYPE kingrest_http_request_duration_seconds_sum counter
+kingrest_http_request_duration_seconds_sum{method="GET",path="/api/v1/users",status="200"} 1
+kingrest_http_request_duration_seconds_sum{method="GET",path="/api/v1/users/:id",status="200"} 0.25
+kingrest_http_request_duration_seconds_sum{method="PATCH",path="/api/v1/users/:id",status="412"} 0.05
+kingrest_http_request_duration_seconds_sum{method="GET",path="NoRoute\"\\",status="404"} 0
+# TYPE kingrest_http_requests_in_flight gauge
+kingrest_http_requests_in_flight 1
+`
+	if !strings.HasPrefix(out, want) {
+		t.Errorf("got\n%s\nwant prefix\n%s", out, want)
+	}
+	for _, metric := range []string{"go_goroutines ", "go_memstats_heap_alloc_bytes "} {
+		if !strings.Contains(out, "\n"+metric) {
+			t.Errorf("%s is missing in\n%s", metric, out)
+		}
+	}
+}
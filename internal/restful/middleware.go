@@ -1,10 +1,11 @@
 package restful
 
 import (
-	"github.com/zliang90/kingRest/internal/restful/api"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zliang90/kingRest/internal/restful/api"
+	"github.com/zliang90/kingRest/internal/restful/errors"
 	"github.com/zliang90/kingRest/pkg/log"
 	"github.com/zliang90/kingRest/pkg/util/uuid"
 )
@@ -13,8 +14,8 @@ func handlerRecovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
-				// api errors
-				api.Failure(c, err)
+				// api errors, with the stack of the panic
+				api.Failure(c, errors.Recovered(err))
 			}
 		}()
 
@@ -66,3 +67,17 @@ func handlerRequestId() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// handlerClientCert set the verified client certificate of mutual tls
+func handlerClientCert() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.TLS != nil && len(c.Request.TLS.VerifiedChains) > 0 &&
+			len(c.Request.TLS.VerifiedChains[0]) > 0 {
+			cert := c.Request.TLS.VerifiedChains[0][0]
+			c.Set("Client-Cert", cert)
+			c.Set("Client-Subject", cert.Subject.String())
+		}
+
+		c.Next()
+	}
+}
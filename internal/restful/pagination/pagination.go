@@ -0,0 +1,290 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+)
+
+// the query parameters, eg:
+//
+//	?page=2&size=20
+//	?limit=20&offset=20
+//	?cursor=eyJvZmZzZXQiOjIwfQ&size=20, the first page is ?cursor=
+const (
+	ParamPage   = "page"
+	ParamSize   = "size"
+	ParamLimit  = "limit"
+	ParamOffset = "offset"
+	ParamCursor = "cursor"
+)
+
+const (
+	modePage = iota
+	modeOffset
+	modeCursor
+)
+
+const (
+	// DefaultSize the page size if it's not specified, see SetSize
+	DefaultSize = 20
+
+	// MaxSize the larger page size is limited to it, see SetSize
+	MaxSize = 100
+)
+
+// the page sizes in effect, swapped on config reloading
+var sizes atomic.Value
+
+// pageSizes the default and max page size
+type pageSizes struct {
+	defaultSize int
+	maxSize     int
+}
+
+func init() {
+	sizes.Store(pageSizes{DefaultSize, MaxSize})
+}
+
+// SetSize set the default and max page size, zero means DefaultSize and MaxSize
+func SetSize(defaultSize, maxSize int) {
+	if defaultSize <= 0 {
+		defaultSize = DefaultSize
+	}
+	if maxSize <= 0 {
+		maxSize = MaxSize
+	}
+	sizes.Store(pageSizes{defaultSize, maxSize})
+}
+
+// Params the pagination parameters of the request
+type Params struct {
+	// 1-based page number, it's 0 in the limit/offset and cursor mode
+	Page   int
+	Size   int
+	Offset int
+
+	mode int
+	url  *url.URL
+}
+
+// Page the pagination of the response
+type Page struct {
+	Total      int
+	Page       int
+	Size       int
+	NextCursor string
+	PrevCursor string
+
+	// the links of first/prev/next/last pages, see Link
+	links []link
+}
+
+type link struct {
+	rel string
+	url string
+}
+
+// cursor the opaque cursor, it's base64 encoded json
+type cursor struct {
+	Offset int `json:"offset"`
+}
+
+// Parse parses the pagination parameters of the request: page/size, limit/offset,
+// or the opaque cursor returned by the previous page, the size is limited to the max size
+func Parse(ctx *gin.Context) (*Params, error) {
+	ps := sizes.Load().(pageSizes)
+	p := &Params{Size: ps.defaultSize, url: ctx.Request.URL}
+	query := ctx.Request.URL.Query()
+
+	size, err := intParam(query, ParamSize, ParamLimit)
+	if err != nil {
+		return nil, err
+	}
+	if size > 0 {
+		p.Size = size
+	}
+	if p.Size > ps.maxSize {
+		p.Size = ps.maxSize
+	}
+
+	modes := 0
+	if _, ok := query[ParamCursor]; ok {
+		// the empty cursor is the first page
+		modes++
+		p.mode = modeCursor
+		if v := query.Get(ParamCursor); v != "" {
+			c, err := decodeCursor(v)
+			if err != nil {
+				return nil, errors.NewBadRequest(fmt.Errorf("invalid %s '%s'", ParamCursor, v))
+			}
+			p.Offset = c.Offset
+		}
+	}
+	if query.Get(ParamOffset) != "" {
+		modes++
+		if p.Offset, err = intParam(query, ParamOffset); err != nil {
+			return nil, err
+		}
+		p.mode = modeOffset
+	}
+	if query.Get(ParamPage) != "" {
+		modes++
+		if p.Page, err = intParam(query, ParamPage); err != nil {
+			return nil, err
+		}
+		if p.Page < 1 {
+			p.Page = 1
+		}
+		p.Offset = (p.Page - 1) * p.Size
+	}
+	if modes > 1 {
+		return nil, errors.NewBadRequest(fmt.Errorf("only one of %s, %s and %s is allowed", ParamPage, ParamOffset, ParamCursor))
+	}
+	if modes == 0 && query.Get(ParamLimit) != "" {
+		p.mode = modeOffset
+	}
+	if p.mode == modePage && p.Page == 0 {
+		p.Page = 1
+	}
+	return p, nil
+}
+
+// Find counts the total of the query, and finds the records of the page into out, eg:
+//
+//	var users []db.User
+//	page, err := p.Find(db.Model(&db.User{}).Order("created_at"), &users)
+func (p *Params) Find(query *gorm.DB, out interface{}) (*Page, error) {
+	var total int
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	if err := query.Offset(p.Offset).Limit(p.Size).Find(out).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return p.NewPage(total), nil
+}
+
+// NewPage returns the page of the total records, with the cursors and links
+func (p *Params) NewPage(total int) *Page {
+	page := &Page{Total: total, Page: p.Page, Size: p.Size}
+	hasPrev := p.Offset > 0
+	hasNext := p.Offset+p.Size < total
+	prevOffset := p.Offset - p.Size
+	if prevOffset < 0 {
+		prevOffset = 0
+	}
+	lastOffset := 0
+	if total > 0 {
+		lastOffset = (total - 1) / p.Size * p.Size
+	}
+
+	switch p.mode {
+	case modePage:
+		lastPage := lastOffset/p.Size + 1
+		page.links = append(page.links, p.link("first", ParamPage, 1))
+		if hasPrev {
+			page.links = append(page.links, p.link("prev", ParamPage, p.Page-1))
+		}
+		if hasNext {
+			page.links = append(page.links, p.link("next", ParamPage, p.Page+1))
+		}
+		page.links = append(page.links, p.link("last", ParamPage, lastPage))
+	case modeOffset:
+		page.links = append(page.links, p.link("first", ParamOffset, 0))
+		if hasPrev {
+			page.links = append(page.links, p.link("prev", ParamOffset, prevOffset))
+		}
+		if hasNext {
+			page.links = append(page.links, p.link("next", ParamOffset, p.Offset+p.Size))
+		}
+		page.links = append(page.links, p.link("last", ParamOffset, lastOffset))
+	case modeCursor:
+		if hasPrev {
+			page.PrevCursor = encodeCursor(cursor{Offset: prevOffset})
+			page.links = append(page.links, p.cursorLink("prev", page.PrevCursor))
+		}
+		if hasNext {
+			page.NextCursor = encodeCursor(cursor{Offset: p.Offset + p.Size})
+			page.links = append(page.links, p.cursorLink("next", page.NextCursor))
+		}
+	}
+	return page
+}
+
+// Link returns the RFC 5988 Link header, eg:
+//
+//	</api/v1/users?page=1&size=20>; rel="first", </api/v1/users?page=3&size=20>; rel="next"
+func (p *Page) Link() string {
+	links := make([]string, 0, len(p.links))
+	for _, l := range p.links {
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, l.url, l.rel))
+	}
+	return strings.Join(links, ", ")
+}
+
+// link returns the url of the request with the page parameter
+func (p *Params) link(rel, param string, value int) link {
+	query := p.url.Query()
+	query.Set(param, strconv.Itoa(value))
+	if param == ParamOffset && query.Get(ParamLimit) == "" {
+		query.Set(ParamLimit, strconv.Itoa(p.Size))
+	} else if param == ParamPage {
+		query.Set(ParamSize, strconv.Itoa(p.Size))
+	}
+	u := *p.url
+	u.RawQuery = query.Encode()
+	return link{rel: rel, url: u.RequestURI()}
+}
+
+func (p *Params) cursorLink(rel, c string) link {
+	query := p.url.Query()
+	query.Set(ParamCursor, c)
+	u := *p.url
+	u.RawQuery = query.Encode()
+	return link{rel: rel, url: u.RequestURI()}
+}
+
+// intParam returns the first non-negative integer of the query parameters
+func intParam(query url.Values, names ...string) (int, error) {
+	for _, name := range names {
+		v := query.Get(name)
+		if v == "" {
+			continue
+		}
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 {
+			return 0, errors.NewBadRequest(fmt.Errorf("invalid %s '%s'", name, v))
+		}
+		return i, nil
+	}
+	return 0, nil
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	if err = json.Unmarshal(data, &c); err != nil {
+		return c, err
+	}
+	if c.Offset < 0 {
+		return c, fmt.Errorf("negative offset")
+	}
+	return c, nil
+}
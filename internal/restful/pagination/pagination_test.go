@@ -0,0 +1,63 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func parse(t *testing.T, uri string) *Params {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("GET", uri, nil)
+	p, err := Parse(ctx)
+	if err != nil {
+		t.Fatalf("%s: %v", uri, err)
+	}
+	return p
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		uri                string
+		page, size, offset int
+	}{
+		{"/users", 1, DefaultSize, 0},
+		{"/users?page=3&size=10", 3, 10, 20},
+		{"/users?limit=5&offset=15", 0, 5, 15},
+		{"/users?size=1000", 1, MaxSize, 0},
+		{"/users?cursor=" + encodeCursor(cursor{Offset: 40}) + "&size=20", 0, 20, 40},
+	}
+	for _, c := range cases {
+		p := parse(t, c.uri)
+		if p.Page != c.page || p.Size != c.size || p.Offset != c.offset {
+			t.Errorf("%s: page %d, size %d, offset %d", c.uri, p.Page, p.Size, p.Offset)
+		}
+	}
+
+	for _, uri := range []string{"/users?page=x", "/users?page=1&offset=2", "/users?cursor=!!", "/users?size=-1"} {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest("GET", uri, nil)
+		if _, err := Parse(ctx); err == nil {
+			t.Errorf("%s: expect error", uri)
+		}
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	page := parse(t, "/users?page=2&size=10").NewPage(35)
+	want := `</users?page=1&size=10>; rel="first", </users?page=1&size=10>; rel="prev", ` +
+		`</users?page=3&size=10>; rel="next", </users?page=4&size=10>; rel="last"`
+	if page.Link() != want {
+		t.Errorf("link = %s", page.Link())
+	}
+
+	page = parse(t, "/users?cursor=&size=10").NewPage(15)
+	if page.PrevCursor != "" || page.NextCursor != encodeCursor(cursor{Offset: 10}) {
+		t.Errorf("cursors = %q, %q", page.PrevCursor, page.NextCursor)
+	}
+	page = parse(t, "/users?cursor="+page.NextCursor+"&size=10").NewPage(15)
+	if page.PrevCursor != encodeCursor(cursor{Offset: 0}) || page.NextCursor != "" {
+		t.Errorf("cursors = %q, %q", page.PrevCursor, page.NextCursor)
+	}
+}
@@ -2,12 +2,14 @@ package router
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/zliang90/kingRest/internal/restful/api"
 	apiV1 "github.com/zliang90/kingRest/internal/restful/api/v1"
 	apiV2 "github.com/zliang90/kingRest/internal/restful/api/v2"
 	"net/http"
 )
 
-func InitRouter(env string) http.Handler {
+// InitRouter register routers, the middleware are applied to all routers
+func InitRouter(env string, middleware ...gin.HandlerFunc) http.Handler {
 	// decide to disable debug
 	if env == "prod" {
 		gin.DisableConsoleColor()
@@ -15,17 +17,32 @@ func InitRouter(env string) http.Handler {
 	}
 
 	engine := gin.New()
+	engine.Use(middleware...)
+
+	/*------------------------------------ health -------------------------------------*/
+	engine.GET("/healthz", api.Healthz)
+	engine.GET("/readyz", api.Readyz)
+
 	/*------------------------------------ api v1 -------------------------------------*/
-	v1 := engine.Group("/api/v1")
+	// the GET responses are cached by ETag
+	v1 := engine.Group("/api/v1", api.HandlerETag())
 
 	{
 		v1.GET("/version", apiV1.Version)
 
+		// catalog of the api errors
+		v1.GET("/errors", apiV1.Errors)
+
+		// users
+		v1.GET("/users", apiV1.ListUsers)
+		v1.GET("/users/:id", apiV1.GetUser)
+		v1.PATCH("/users/:id", api.HandlerPrecondition(apiV1.UserVersion), apiV1.UpdateUser)
+
 		// configuration
 	}
 
 	/*------------------------------------ api v2 -------------------------------------*/
-	v2 := engine.Group("/api/v2")
+	v2 := engine.Group("/api/v2", api.HandlerETag())
 	{
 		v2.GET("/version", apiV2.Version)
 	}
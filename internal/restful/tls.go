@@ -0,0 +1,186 @@
+package restful
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/zliang90/kingRest/internal/app/conf"
+	"github.com/zliang90/kingRest/pkg/log"
+)
+
+// interval of checking the modification of certificate files
+const tlsReloadInterval = 10 * time.Second
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"":                   tls.NoClientCert,
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+// tlsReloader builds the tls config, and rebuilds it once the certificate
+// or client CA files are modified
+type tlsReloader struct {
+	cf conf.TLS
+
+	mu        sync.RWMutex
+	config    *tls.Config
+	modTimes  map[string]time.Time
+	checkedAt time.Time
+}
+
+func newTLSReloader(cf conf.TLS) (*tlsReloader, error) {
+	r := &tlsReloader{cf: cf}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig returns the tls config of the server, GetCertificate is also set
+// for http.Server.ServeTLS, which loads the certificate files unless the
+// config has certificates or GetCertificate
+func (r *tlsReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.checkModified()
+
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.config, nil
+		},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			r.checkModified()
+
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return &r.config.Certificates[0], nil
+		},
+	}
+}
+
+// checkModified reload the files once modified, the errors are logged
+// and the previous config is kept
+func (r *tlsReloader) checkModified() {
+	r.mu.RLock()
+	due := time.Since(r.checkedAt) >= tlsReloadInterval
+	r.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	r.mu.Lock()
+	r.checkedAt = time.Now()
+	changed := false
+	for f, t := range r.modTimes {
+		if fi, err := os.Stat(f); err == nil && !fi.ModTime().Equal(t) {
+			changed = true
+		}
+	}
+	r.mu.Unlock()
+
+	if changed {
+		log.Info("tls files changed, reload certificates")
+		if err := r.reload(); err != nil {
+			log.Errorf("reload tls certificates failed, keep the previous ones: %v", err)
+		}
+	}
+}
+
+func (r *tlsReloader) reload() error {
+	files := []string{r.cf.CertFile, r.cf.KeyFile}
+	if r.cf.ClientCAFile != "" {
+		files = append(files, r.cf.ClientCAFile)
+	}
+	modTimes := make(map[string]time.Time)
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		modTimes[f] = fi.ModTime()
+	}
+
+	c, err := buildTLSConfig(r.cf)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.config = c
+	r.modTimes = modTimes
+	r.checkedAt = time.Now()
+	return nil
+}
+
+func buildTLSConfig(cf conf.TLS) (*tls.Config, error) {
+	if err := cf.Validate(); err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(cf.CertFile, cf.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	c := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	if cf.MinVersion != "" {
+		v, ok := tlsVersions[cf.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported tls version: '%s'", cf.MinVersion)
+		}
+		c.MinVersion = v
+	}
+
+	if len(cf.CipherSuites) > 0 {
+		suites := make(map[string]uint16)
+		for _, s := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+			suites[s.Name] = s.ID
+		}
+		for _, name := range cf.CipherSuites {
+			id, ok := suites[name]
+			if !ok {
+				return nil, fmt.Errorf("unsupported tls cipher suite: '%s'", name)
+			}
+			c.CipherSuites = append(c.CipherSuites, id)
+		}
+	}
+
+	authType, ok := clientAuthTypes[cf.ClientAuth]
+	if !ok {
+		return nil, fmt.Errorf("unsupported tls client auth: '%s'", cf.ClientAuth)
+	}
+	c.ClientAuth = authType
+
+	if cf.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(cf.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in '%s'", cf.ClientCAFile)
+		}
+		c.ClientCAs = pool
+	}
+	return c, nil
+}
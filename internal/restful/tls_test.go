@@ -0,0 +1,234 @@
+package restful
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zliang90/kingRest/internal/app/conf"
+)
+
+// writeCert writes a self-signed certificate and its key to dir,
+// returns the certificate and key files
+func writeCert(t *testing.T, dir, name, commonName string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	if err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kingrest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := writeCert(t, dir, "server", "server")
+	caFile, _ := writeCert(t, dir, "ca", "ca")
+
+	cases := []struct {
+		name string
+		cf   conf.TLS
+		err  string
+	}{
+		{"default", conf.TLS{}, ""},
+		{"min version", conf.TLS{MinVersion: "1.3"}, ""},
+		{"unsupported version", conf.TLS{MinVersion: "2.0"}, "unsupported tls version"},
+		{"cipher suites", conf.TLS{CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}}, ""},
+		{"unsupported cipher suite", conf.TLS{CipherSuites: []string{"TLS_FOO"}}, "unsupported tls cipher suite"},
+		{"request without CA", conf.TLS{ClientAuth: "request"}, ""},
+		{"verify with CA", conf.TLS{ClientAuth: "require_and_verify", ClientCAFile: caFile}, ""},
+		{"verify if given with CA", conf.TLS{ClientAuth: "verify_if_given", ClientCAFile: caFile}, ""},
+		{"verify without CA", conf.TLS{ClientAuth: "require_and_verify"}, "ClientCAFile is required"},
+		{"verify if given without CA", conf.TLS{ClientAuth: "verify_if_given"}, "ClientCAFile is required"},
+		{"CA without client auth", conf.TLS{ClientCAFile: caFile}, "without ClientAuth"},
+		{"CA without certificate", conf.TLS{ClientAuth: "require_and_verify", ClientCAFile: keyFile}, "no certificate found"},
+		{"unsupported client auth", conf.TLS{ClientAuth: "always"}, "unsupported tls client auth"},
+	}
+	for _, c := range cases {
+		c.cf.CertFile, c.cf.KeyFile = certFile, keyFile
+		tc, err := buildTLSConfig(c.cf)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("%s: got error %v, want %s", c.name, err, c.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if c.cf.ClientCAFile != "" && tc.ClientCAs == nil {
+			t.Errorf("%s: the client CA is not loaded", c.name)
+		}
+		if c.cf.ClientAuth != "" && tc.ClientAuth != clientAuthTypes[c.cf.ClientAuth] {
+			t.Errorf("%s: got client auth %v", c.name, tc.ClientAuth)
+		}
+	}
+}
+
+func TestTLSReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kingrest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := writeCert(t, dir, "server", "old")
+	r, err := newTLSReloader(conf.TLS{CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commonName := func() string {
+		c, err := r.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(c.Certificates[0].Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the same certificate for ServeTLS of the older go
+		if tc, err := r.TLSConfig().GetCertificate(&tls.ClientHelloInfo{}); err != nil || !bytes.Equal(tc.Certificate[0], cert.Raw) {
+			t.Errorf("GetCertificate differs from GetConfigForClient: %v", err)
+		}
+		return cert.Subject.CommonName
+	}
+	// modify the files and skip the check interval
+	touch := func() {
+		future := time.Now().Add(time.Minute)
+		for _, f := range []string{certFile, keyFile} {
+			if err := os.Chtimes(f, future, future); err != nil {
+				t.Fatal(err)
+			}
+		}
+		r.mu.Lock()
+		r.checkedAt = time.Time{}
+		r.mu.Unlock()
+	}
+
+	if cn := commonName(); cn != "old" {
+		t.Fatalf("got certificate %s", cn)
+	}
+
+	// not reloaded within the check interval
+	writeCert(t, dir, "server", "new")
+	if cn := commonName(); cn != "old" {
+		t.Errorf("reloaded within the interval: %s", cn)
+	}
+
+	touch()
+	if cn := commonName(); cn != "new" {
+		t.Errorf("got certificate %s, want new", cn)
+	}
+
+	// the broken certificate is not applied
+	if err = ioutil.WriteFile(certFile, []byte("broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	touch()
+	if cn := commonName(); cn != "new" {
+		t.Errorf("got certificate %s after the broken one, want new", cn)
+	}
+}
+
+func TestTLSClientCert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kingrest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := writeCert(t, dir, "server", "server")
+	clientCert, clientKey := writeCert(t, dir, "client", "client")
+	r, err := newTLSReloader(conf.TLS{CertFile: certFile, KeyFile: keyFile,
+		ClientAuth: "verify_if_given", ClientCAFile: clientCert})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(handlerClientCert())
+	engine.GET("/", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, ctx.GetString("Client-Subject"))
+	})
+
+	// served as the engine does, the certificates are of the tls config
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: engine, TLSConfig: r.TLSConfig()}
+	go srv.ServeTLS(l, "", "")
+	defer srv.Close()
+
+	cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(certs []tls.Certificate) string {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			Certificates: certs,
+			// the self-signed server certificate has no SANs
+			InsecureSkipVerify: true,
+		}}}
+		resp, err := client.Get("https://" + l.Addr().String() + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	if subject := get([]tls.Certificate{cert}); subject != "CN=client" {
+		t.Errorf("got client subject %q, want CN=client", subject)
+	}
+	if subject := get(nil); subject != "" {
+		t.Errorf("got client subject %q without the certificate", subject)
+	}
+}
@@ -0,0 +1,152 @@
+package restful
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zliang90/kingRest/pkg/log"
+)
+
+const (
+	// the listener names passed to the new process, in the order of file descriptors
+	envUpgradeFdNames = "KINGREST_LISTEN_FDNAMES"
+
+	// the file descriptor of the pipe to report ready to the parent process
+	envUpgradeReadyFd = "KINGREST_READY_FD"
+
+	readyMessage = "ready"
+)
+
+// Upgrade start the new binary with the listeners in use, and wait until it reports
+// ready, then the caller should drain and exit by the normal shutdown path.
+// the new process is killed if it doesn't report ready before timeout
+func Upgrade(timeout time.Duration) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	activeLock.Lock()
+	names := make([]string, 0, len(active))
+	for name := range active {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	files := make([]*os.File, 0, len(names)+1)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, name := range names {
+		f, err := listenerFile(active[name])
+		if err != nil {
+			activeLock.Unlock()
+			return fmt.Errorf("listener of '%s' can't be handed off, %v", name, err)
+		}
+		files = append(files, f)
+	}
+	activeLock.Unlock()
+
+	// ready pipe
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	files = append(files, w)
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		envUpgradeFdNames+"="+strings.Join(names, ":"),
+		envUpgradeReadyFd+"="+strconv.Itoa(listenFdsStart+len(names)))
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	// the write end is only held by the new process
+	w.Close()
+	files = files[:len(files)-1]
+
+	log.Infof("started new process %d, waiting for it to be ready", cmd.Process.Pid)
+
+	ready := make(chan error, 1)
+	go func() {
+		line, err := bufio.NewReader(r).ReadString('\n')
+		if strings.TrimSpace(line) == readyMessage {
+			ready <- nil
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("unexpected message: %s", line)
+		}
+		ready <- fmt.Errorf("new process exited before ready, %v", err)
+	}()
+
+	select {
+	case err = <-ready:
+	case <-time.After(timeout):
+		err = fmt.Errorf("new process is not ready after %v", timeout)
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+
+	// the socket files are kept for the new process
+	activeLock.Lock()
+	for _, l := range active {
+		if ul, ok := l.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+	activeLock.Unlock()
+
+	log.Infof("new process %d is ready", cmd.Process.Pid)
+	go cmd.Wait()
+	return nil
+}
+
+// NotifyReady report ready to the parent process if it's started by Upgrade
+func NotifyReady() {
+	v, ok := os.LookupEnv(envUpgradeReadyFd)
+	if !ok {
+		return
+	}
+	os.Unsetenv(envUpgradeReadyFd)
+
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		log.Errorf("invalid %s: %s", envUpgradeReadyFd, v)
+		return
+	}
+	f := os.NewFile(uintptr(fd), "ready")
+	defer f.Close()
+
+	if _, err = f.WriteString(readyMessage + "\n"); err != nil {
+		log.Errorf("report ready to the parent process failed: %v", err)
+	}
+}
+
+func listenerFile(l net.Listener) (*os.File, error) {
+	switch t := l.(type) {
+	case *net.TCPListener:
+		return t.File()
+	case *net.UnixListener:
+		return t.File()
+	default:
+		return nil, fmt.Errorf("unsupported listener type %T", l)
+	}
+}
@@ -0,0 +1,108 @@
+//go:build !windows
+// +build !windows
+
+package restful
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// the behavior of the new process started by Upgrade: ready, fail or hang
+const envTestUpgrade = "KINGREST_TEST_UPGRADE"
+
+// TestMain runs as the new process if it's started by Upgrade, the test
+// binary is the executable of Upgrade
+func TestMain(m *testing.M) {
+	if _, ok := os.LookupEnv(envUpgradeFdNames); ok {
+		os.Exit(upgradedProcess())
+	}
+	os.Exit(m.Run())
+}
+
+// upgradedProcess accepts a connection on the inherited listener and
+// responds "upgraded"
+func upgradedProcess() int {
+	switch os.Getenv(envTestUpgrade) {
+	case "fail":
+		return 1
+	case "hang":
+		time.Sleep(time.Minute)
+		return 0
+	}
+
+	l, err := newListener(listenerWeb, "127.0.0.1:0", "")
+	if err != nil {
+		return 1
+	}
+	NotifyReady()
+
+	conn, err := l.Accept()
+	if err != nil {
+		return 1
+	}
+	defer conn.Close()
+	conn.Write([]byte("upgraded\n"))
+	return 0
+}
+
+func TestUpgrade(t *testing.T) {
+	l, err := listen(listenerWeb, "127.0.0.1:0", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		activeLock.Lock()
+		delete(active, listenerWeb)
+		activeLock.Unlock()
+	}()
+
+	cases := []struct {
+		behavior string
+		timeout  time.Duration
+		err      string
+	}{
+		{"fail", 5 * time.Second, "exited before ready"},
+		{"hang", 200 * time.Millisecond, "not ready after"},
+	}
+	for _, c := range cases {
+		os.Setenv(envTestUpgrade, c.behavior)
+		err := Upgrade(c.timeout)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: got error %v, want %s", c.behavior, err, c.err)
+		}
+	}
+	os.Unsetenv(envTestUpgrade)
+
+	// the listener is kept after the failed upgrades, the connection is
+	// accepted here so that the new process doesn't accept it
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("the listener is closed by the failed upgrades: %v", err)
+	}
+	conn.Close()
+	if c, err := l.Accept(); err == nil {
+		c.Close()
+	}
+
+	if err = Upgrade(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	// the parent drains, the new process serves on the same address
+	addr := l.Addr().String()
+	l.Close()
+
+	if conn, err = net.Dial("tcp", addr); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || line != "upgraded\n" {
+		t.Errorf("got %q %v from the new process", line, err)
+	}
+}
@@ -0,0 +1,122 @@
+package validator
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+	"github.com/zliang90/kingRest/pkg/log"
+)
+
+// the translator of validation messages, keyed by language
+var uni = ut.New(en.New(), en.New(), zh.New())
+
+// FieldError field-level validation error
+type FieldError struct {
+	// field path of json tag names, eg: "address.city"
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+
+	fe validator.FieldError
+}
+
+// FieldErrors field-level validation errors, used as APIError.Details
+type FieldErrors []FieldError
+
+// NewFieldErrors converts the validation errors to field errors,
+// the messages are in the default locale
+func NewFieldErrors(errs validator.ValidationErrors) FieldErrors {
+	fes := make(FieldErrors, 0, len(errs))
+	for _, fe := range errs {
+		fes = append(fes, FieldError{
+			Field: fieldPath(fe),
+			Rule:  fe.Tag(),
+			Param: fe.Param(),
+			fe:    fe,
+		})
+	}
+	fes.Localize("")
+	return fes
+}
+
+// Localize translate the messages to the locale best matching the Accept-Language
+func (fes FieldErrors) Localize(acceptLanguage string) {
+	trans := translator(acceptLanguage)
+	for i := range fes {
+		fes[i].Message = translate(fes[i].fe, trans)
+	}
+}
+
+// BadRequest returns BAD_REQUEST api error, the validation errors of
+// binding are converted to field errors as details
+func BadRequest(err error) *errors.APIError {
+	e := errors.NewBadRequest(err)
+	if errs, ok := err.(validator.ValidationErrors); ok {
+		e.Details = NewFieldErrors(errs)
+	}
+	return e
+}
+
+// translator returns the translator of the first supported language
+// in Accept-Language, or the default locale
+func translator(acceptLanguage string) ut.Translator {
+	for _, part := range append(strings.Split(acceptLanguage, ","), errors.GetSettings().DefaultLocale) {
+		lang := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		lang = strings.ToLower(strings.SplitN(strings.Replace(lang, "_", "-", -1), "-", 2)[0])
+		if trans, ok := uni.GetTranslator(lang); ok && lang != "" {
+			return trans
+		}
+	}
+	return uni.GetFallback()
+}
+
+func translate(fe validator.FieldError, trans ut.Translator) string {
+	msg := fe.Translate(trans)
+	if msg == fe.Error() {
+		// no translation of the rule
+		msg = fe.Field() + " failed on the '" + fe.Tag() + "' rule"
+	}
+	return msg
+}
+
+// fieldPath returns the namespace without the top-level struct name
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
+
+// jsonTagName returns the field name of json tag, or form tag for query binding
+func jsonTagName(fld reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name := strings.SplitN(fld.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+func registerTranslations(v *validator.Validate) {
+	enTrans, _ := uni.GetTranslator("en")
+	if err := enTranslations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		log.Errorf("register en validation translations failed: %v", err)
+	}
+	zhTrans, _ := uni.GetTranslator("zh")
+	if err := zhTranslations.RegisterDefaultTranslations(v, zhTrans); err != nil {
+		log.Errorf("register zh validation translations failed: %v", err)
+	}
+}
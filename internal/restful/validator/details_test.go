@@ -0,0 +1,119 @@
+package validator
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/zliang90/kingRest/internal/restful/errors"
+)
+
+type testAddress struct {
+	City string `json:"city" validate:"required"`
+	Zip  string `json:"zip_code" validate:"len=6"`
+}
+
+type testUser struct {
+	Name     string        `json:"name" validate:"required,max=8"`
+	Age      int           `json:"age" validate:"gte=18"`
+	Email    string        `json:"email,omitempty" validate:"omitempty,email"`
+	Address  testAddress   `json:"address"`
+	Contacts []testAddress `json:"contacts" validate:"dive"`
+	Role     string        `form:"role" validate:"oneof=admin user"`
+	Internal string        `json:"-" validate:"required"`
+}
+
+func validateUser(t *testing.T, u testUser) validator.ValidationErrors {
+	err := new(defaultValidator).ValidateStruct(&u)
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		t.Fatalf("got %T %v, want validation errors", err, err)
+	}
+	return errs
+}
+
+func TestNewFieldErrors(t *testing.T) {
+	defer errors.SetDefaultLocale("zh-CN")
+	errors.SetDefaultLocale("en-US")
+
+	fes := NewFieldErrors(validateUser(t, testUser{
+		Name:     "too long name",
+		Age:      16,
+		Email:    "x",
+		Address:  testAddress{Zip: "1"},
+		Contacts: []testAddress{{City: "Beijing", Zip: "100000"}, {Zip: "100000"}},
+		Role:     "root",
+	}))
+
+	want := []FieldError{
+		{Field: "name", Rule: "max", Param: "8", Message: "name must be a maximum of 8 characters in length"},
+		{Field: "age", Rule: "gte", Param: "18", Message: "age must be 18 or greater"},
+		{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		{Field: "address.city", Rule: "required", Message: "city is a required field"},
+		{Field: "address.zip_code", Rule: "len", Param: "6", Message: "zip_code must be 6 characters in length"},
+		{Field: "contacts[1].city", Rule: "required", Message: "city is a required field"},
+		{Field: "role", Rule: "oneof", Param: "admin user", Message: "role must be one of [admin user]"},
+		{Field: "Internal", Rule: "required", Message: "Internal is a required field"},
+	}
+	if len(fes) != len(want) {
+		t.Fatalf("got %d field errors %+v, want %d", len(fes), fes, len(want))
+	}
+	for i, fe := range fes {
+		w := want[i]
+		if fe.Field != w.Field || fe.Rule != w.Rule || fe.Param != w.Param || fe.Message != w.Message {
+			t.Errorf("field error %d = {%s %s %s %q}, want {%s %s %s %q}",
+				i, fe.Field, fe.Rule, fe.Param, fe.Message, w.Field, w.Rule, w.Param, w.Message)
+		}
+	}
+}
+
+func TestFieldErrorsLocalize(t *testing.T) {
+	errs := validateUser(t, testUser{Name: "kingrest", Age: 18, Role: "user", Internal: "x",
+		Address: testAddress{City: "Beijing"}})
+
+	cases := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", "zip_code长度必须是6个字符"},
+		{"en-US", "zip_code must be 6 characters in length"},
+		{"en-GB,zh;q=0.5", "zip_code must be 6 characters in length"},
+		{"fr, zh-CN;q=0.8", "zip_code长度必须是6个字符"},
+		{"zh_TW", "zip_code长度必须是6个字符"},
+		{"fr", "zip_code长度必须是6个字符"},
+	}
+	for _, c := range cases {
+		fes := NewFieldErrors(errs)
+		fes.Localize(c.acceptLanguage)
+		if len(fes) != 1 || fes[0].Message != c.want {
+			t.Errorf("%q: got %+v, want %q", c.acceptLanguage, fes, c.want)
+		}
+	}
+}
+
+func TestBadRequest(t *testing.T) {
+	if err := errors.LoadMessages("../../../config/errors.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := validateUser(t, testUser{Age: 18, Role: "user", Internal: "x", Address: testAddress{City: "Beijing", Zip: "100000"}})
+	e := BadRequest(errs)
+	if e.HTTPStatus() != http.StatusBadRequest {
+		t.Errorf("unexpected status %d", e.HTTPStatus())
+	}
+	fes, ok := e.Details.(FieldErrors)
+	if !ok || len(fes) != 1 || fes[0].Field != "name" {
+		t.Fatalf("unexpected details %#v", e.Details)
+	}
+
+	// the details are localized with the api error
+	e.Localize("en-US")
+	if fes := e.Details.(FieldErrors); fes[0].Message != "name is a required field" {
+		t.Errorf("unexpected message %q", fes[0].Message)
+	}
+
+	// not a validation error
+	if e = BadRequest(http.ErrBodyNotAllowed); e.Details != nil {
+		t.Errorf("unexpected details %#v", e.Details)
+	}
+}
@@ -0,0 +1,217 @@
+package validator
+
+import (
+	"strconv"
+	"sync"
+	"unicode"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/zliang90/kingRest/pkg/log"
+	"github.com/zliang90/kingRest/pkg/util/time"
+	"github.com/zliang90/kingRest/pkg/util/uuid"
+)
+
+// the custom rules registered by RegisterValidation etc., which are applied
+// to every attached validator instance, including the one registered later
+var (
+	rulesLock sync.Mutex
+
+	rules       []rule
+	structRules []structRule
+	aliases     []tagAlias
+	messages    []message
+
+	// checks the rules before they are applied to the attached instances
+	check    = validator.New()
+	attached []*validator.Validate
+)
+
+type rule struct {
+	tag            string
+	fn             validator.Func
+	callEvenIfNull bool
+}
+
+type structRule struct {
+	fn    validator.StructLevelFunc
+	types []interface{}
+}
+
+type tagAlias struct {
+	alias string
+	tags  string
+}
+
+type message struct {
+	tag  string
+	lang string
+	text string
+}
+
+func init() {
+	if err := RegisterValidation("uuid_id", isUUID); err != nil {
+		log.Fatal(err)
+	}
+	if err := RegisterValidation("hourminute", isHourMinute); err != nil {
+		log.Fatal(err)
+	}
+	if err := RegisterValidation("strong_password", isStrongPassword); err != nil {
+		log.Fatal(err)
+	}
+
+	RegisterTranslation("uuid_id", "en", "{0} must be a valid UUID")
+	RegisterTranslation("uuid_id", "zh", "{0}必须是一个有效的UUID")
+	RegisterTranslation("hourminute", "en", "{0} must be a valid time of day, eg: 08:30")
+	RegisterTranslation("hourminute", "zh", "{0}必须是一个有效的时间, 例如: 08:30")
+	RegisterTranslation("strong_password", "en", "{0} must contain upper and lower case letters, digits and symbols")
+	RegisterTranslation("strong_password", "zh", "{0}必须包含大小写字母、数字和符号")
+}
+
+// Attach applies the registered rules to the validator instance,
+// and the rules registered later as well
+func Attach(v *validator.Validate) {
+	rulesLock.Lock()
+	defer rulesLock.Unlock()
+
+	for _, r := range rules {
+		_ = v.RegisterValidation(r.tag, r.fn, r.callEvenIfNull)
+	}
+	for _, r := range structRules {
+		v.RegisterStructValidation(r.fn, r.types...)
+	}
+	for _, a := range aliases {
+		v.RegisterAlias(a.alias, a.tags)
+	}
+	for _, m := range messages {
+		registerMessage(v, m)
+	}
+	attached = append(attached, v)
+}
+
+// RegisterValidation registers the validation func of the custom tag, eg:
+//
+//	validator.RegisterValidation("hourminute", func(fl validator.FieldLevel) bool {...})
+//
+// it's not thread-safe to register rules after validating, call it on init
+func RegisterValidation(tag string, fn validator.Func, callValidationEvenIfNull ...bool) error {
+	rulesLock.Lock()
+	defer rulesLock.Unlock()
+
+	if err := check.RegisterValidation(tag, fn, callValidationEvenIfNull...); err != nil {
+		return err
+	}
+	r := rule{tag: tag, fn: fn, callEvenIfNull: len(callValidationEvenIfNull) > 0 && callValidationEvenIfNull[0]}
+	for _, v := range attached {
+		_ = v.RegisterValidation(r.tag, r.fn, r.callEvenIfNull)
+	}
+	rules = append(rules, r)
+	return nil
+}
+
+// RegisterStructValidation registers the struct-level validation func of the types,
+// it's useful to validate the fields depending on each other
+func RegisterStructValidation(fn validator.StructLevelFunc, types ...interface{}) {
+	rulesLock.Lock()
+	defer rulesLock.Unlock()
+
+	for _, v := range attached {
+		v.RegisterStructValidation(fn, types...)
+	}
+	structRules = append(structRules, structRule{fn: fn, types: types})
+}
+
+// RegisterAlias registers the alias of the tags, eg:
+//
+//	validator.RegisterAlias("username", "required,min=3,max=32,alphanum")
+func RegisterAlias(alias, tags string) {
+	rulesLock.Lock()
+	defer rulesLock.Unlock()
+
+	check.RegisterAlias(alias, tags)
+	for _, v := range attached {
+		v.RegisterAlias(alias, tags)
+	}
+	aliases = append(aliases, tagAlias{alias: alias, tags: tags})
+}
+
+// RegisterTranslation registers the message of the tag in the language (en/zh),
+// {0} is replaced by the field name and {1} by the param, eg:
+//
+//	validator.RegisterTranslation("hourminute", "en", "{0} must be a valid time of day")
+func RegisterTranslation(tag, lang, text string) {
+	rulesLock.Lock()
+	defer rulesLock.Unlock()
+
+	m := message{tag: tag, lang: lang, text: text}
+	for _, v := range attached {
+		registerMessage(v, m)
+	}
+	messages = append(messages, m)
+}
+
+func registerMessage(v *validator.Validate, m message) {
+	trans, ok := uni.GetTranslator(m.lang)
+	if !ok {
+		log.Errorf("no translator of validation messages in %s", m.lang)
+		return
+	}
+	err := v.RegisterTranslation(m.tag, trans,
+		func(trans ut.Translator) error {
+			return trans.Add(m.tag, m.text, true)
+		},
+		func(trans ut.Translator, fe validator.FieldError) string {
+			msg, err := trans.T(fe.Tag(), fe.Field(), fe.Param())
+			if err != nil {
+				return fe.Error()
+			}
+			return msg
+		})
+	if err != nil {
+		log.Errorf("register validation translation of %s failed: %v", m.tag, err)
+	}
+}
+
+// isUUID the field is a valid uuid string
+func isUUID(fl validator.FieldLevel) bool {
+	return uuid.VerifyUUID(fl.Field().String())
+}
+
+// isHourMinute the field is a valid time of day, eg: 08:30
+func isHourMinute(fl validator.FieldLevel) bool {
+	_, _, err := time.ParseHourMinuteString(fl.Field().String())
+	return err == nil
+}
+
+// isStrongPassword the field contains upper and lower case letters, digits and symbols,
+// and is not shorter than the param, default 8
+func isStrongPassword(fl validator.FieldLevel) bool {
+	minLen := 8
+	if p := fl.Param(); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return false
+		}
+		minLen = n
+	}
+
+	password := fl.Field().String()
+	if len([]rune(password)) < minLen {
+		return false
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	return upper && lower && digit && symbol
+}
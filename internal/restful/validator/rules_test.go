@@ -0,0 +1,46 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestBuiltinRules(t *testing.T) {
+	v := validator.New()
+	Attach(v)
+
+	cases := []struct {
+		value string
+		tag   string
+		valid bool
+	}{
+		{"3f1c8f5e-2b7a-4e0b-9a3c-6d2b1f0e4a11", "uuid_id", true},
+		{"3f1c8f5e-2b7a", "uuid_id", false},
+		{"08:30", "hourminute", true},
+		{"24:00", "hourminute", false},
+		{"8:30", "hourminute", false},
+		{"Passw0rd!", "strong_password", true},
+		{"Passw0rd!", "strong_password=12", false},
+		{"password0!", "strong_password", false},
+		{"Password!", "strong_password", false},
+	}
+	for _, c := range cases {
+		if err := v.Var(c.value, c.tag); (err == nil) != c.valid {
+			t.Errorf("%s of %q valid = %v, want %v", c.tag, c.value, err == nil, c.valid)
+		}
+	}
+}
+
+func TestRegisterAfterAttach(t *testing.T) {
+	v := validator.New()
+	Attach(v)
+
+	RegisterAlias("test_username", "required,min=3,alphanum")
+	if err := v.Var("ab", "test_username"); err == nil {
+		t.Error("alias registered after attach not applied")
+	}
+	if err := v.Var("abc", "test_username"); err != nil {
+		t.Error(err)
+	}
+}
@@ -36,12 +36,31 @@ func (v *defaultValidator) Engine() interface{} {
 
 func (v *defaultValidator) lazyinit() {
 	v.once.Do(func() {
-		v.validate = validator.New()
-		// v.validate.SetTagName("binding")
-		v.validate.SetTagName("validate")
+		v.validate = sharedValidate()
+	})
+}
+
+var (
+	shared     *validator.Validate
+	sharedOnce sync.Once
+)
+
+// sharedValidate returns the validate engine shared by the binding validators,
+// the translations can be registered to the translators only once
+func sharedValidate() *validator.Validate {
+	sharedOnce.Do(func() {
+		shared = validator.New()
+		// shared.SetTagName("binding")
+		shared.SetTagName("validate")
+
+		// field errors are reported by json tag names
+		shared.RegisterTagNameFunc(jsonTagName)
+		registerTranslations(shared)
 
-		// add any custom validations etc. here
+		// custom validations, see RegisterValidation
+		Attach(shared)
 	})
+	return shared
 }
 
 func kindOfData(data interface{}) reflect.Kind {
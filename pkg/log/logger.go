@@ -32,6 +32,23 @@ const (
 	numLevel = 5
 )
 
+func (s Level) String() string {
+	if s >= 0 && int(s) < len(levelName) {
+		return levelName[s]
+	}
+	return "UNKNOWN"
+}
+
+// ParseLevel parse the level name, eg: "debug", "INFO"
+func ParseLevel(name string) (Level, error) {
+	for i, n := range levelName {
+		if strings.EqualFold(n, name) {
+			return Level(i), nil
+		}
+	}
+	return DEBUG, fmt.Errorf("unknown log level: '%s'", name)
+}
+
 type Backend interface {
 	Log(s Level, msg []byte)
 	close()
@@ -379,6 +396,10 @@ func SetLevel(level interface{}) {
 	logging.SetLevel(level)
 }
 
+func GetLevel() Level {
+	return logging.GetLevel(0)
+}
+
 func Close() {
 	logging.Close()
 }